@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"net/url"
+	"os"
+	"path/filepath"
 
-	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/fanny7d/semrel-gitlab/pkg/actions"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	"github.com/spf13/cobra"
 )
 
@@ -21,53 +23,33 @@ var addDownloadCmd = &cobra.Command{
 			return fmt.Errorf("file 是必需的")
 		}
 
-		tag, _ := cmd.Flags().GetString("ci-commit-tag")
+		tag := settings.CI.CommitTag
 		if tag == "" {
 			return fmt.Errorf("ci-commit-tag 是必需的")
 		}
 
-		// 获取全局选项
-		token := cmd.Flag("token").Value.String()
-		if token == "" {
-			return fmt.Errorf("token 是必需的")
-		}
-
-		apiURL := cmd.Flag("gl-api").Value.String()
-		if apiURL == "" {
-			return fmt.Errorf("gl-api 是必需的")
-		}
-
-		project := cmd.Flag("ci-project-path").Value.String()
-		if project == "" {
-			return fmt.Errorf("ci-project-path 是必需的")
-		}
-
-		projectURLStr := cmd.Flag("ci-project-url").Value.String()
-		if projectURLStr == "" {
-			return fmt.Errorf("ci-project-url 是必需的")
-		}
-
-		projectURL, err := url.Parse(projectURLStr)
-		if err != nil {
-			return fmt.Errorf("解析 project-url 失败: %v", err)
+		if err := requireGitLab(); err != nil {
+			return err
 		}
-
-		skipSSLVerify, _ := cmd.Flags().GetBool("skip-ssl-verify")
-
-		// 创建服务
-		gitlabService, err := service.NewGitLabService(token, apiURL, project, projectURL, skipSSLVerify)
+		projectURL, err := settings.ProjectURL()
 		if err != nil {
 			return err
 		}
 
-		// 获取标签
-		release, err := gitlabService.GetTag(tag)
+		// 创建 GitLab 客户端
+		client, err := newGitLabClient()
 		if err != nil {
 			return err
 		}
-
-		// 上传文件
-		if err := gitlabService.UploadFile(release, file); err != nil {
+		project := settings.CI.ProjectPath
+
+		// 确认标签存在，上传文件并添加到发布
+		getTag := actions.NewGetTag(client, project, tag)
+		upload := actions.NewUpload(client, project, projectURL, file)
+		link := actions.NewCreateReleaseLink(client, project, getTag.TagFunc(), filepath.Base(file), upload.LinkURLFunc())
+		actionList := []workflow.Action{getTag, upload, link}
+		applied, err := runWorkflow(cmd.Context(), actionList)
+		if err != nil || !applied {
 			return err
 		}
 
@@ -81,5 +63,5 @@ func init() {
 
 	// 命令特定选项
 	addDownloadCmd.Flags().StringP("file", "f", "", "要上传的文件")
-	addDownloadCmd.Flags().String("ci-commit-tag", "", "要添加下载的标签")
+	addDownloadCmd.Flags().String("ci-commit-tag", os.Getenv("CI_COMMIT_TAG"), "要添加下载的标签。如果未定义，则使用 CI_COMMIT_TAG 环境变量")
 }
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/fanny7d/semrel-gitlab/pkg/domain"
-	"github.com/fanny7d/semrel-gitlab/pkg/service"
 	"github.com/spf13/cobra"
 )
 
@@ -23,28 +22,38 @@ var changelogCmd = &cobra.Command{
 - 提交信息
 - 提交者信息
 
-变更日志将写入到 CHANGELOG.md 文件中。`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// 获取全局选项
-		patchTypes := strings.Split(cmd.Flag("patch-commit-types").Value.String(), ",")
-		minorTypes := strings.Split(cmd.Flag("minor-commit-types").Value.String(), ",")
-		tagPrefix := cmd.Flag("tag-prefix").Value.String()
-
-		// 创建 Git 服务
-		gitService := service.NewGitService(patchTypes, minorTypes, tagPrefix)
+变更日志将写入到 CHANGELOG.md 文件中。使用 --component 或 --all-components 时，
+每个组件的变更日志写入到组件配置的 changelog 文件中。
 
+--output 为 json、yaml 或 dotenv 时不写入文件，而是输出与 next-version 相同结构的发布信息。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// 分析提交
-		release, err := gitService.AnalyzeCommits()
+		targets, err := analyzeTargets()
 		if err != nil {
 			return err
 		}
 
-		// 生成变更日志
-		changelog := generateChangelog(release)
+		if machineOutput() {
+			return printReleases(os.Stdout, targets, multipleTargets(targets))
+		}
 
-		// 写入文件
-		if err := os.WriteFile("CHANGELOG.md", []byte(changelog), 0644); err != nil {
-			return fmt.Errorf("写入变更日志失败: %v", err)
+		for _, target := range targets {
+			// 同时处理多个组件时跳过没有变更的组件
+			if multipleTargets(targets) && !target.release.HasContent() {
+				continue
+			}
+
+			// 生成变更日志
+			changelog := generateChangelog(target.release)
+
+			// 写入文件
+			file := "CHANGELOG.md"
+			if target.component != nil {
+				file = target.component.Changelog
+			}
+			if err := os.WriteFile(file, []byte(changelog), 0644); err != nil {
+				return fmt.Errorf("写入变更日志失败: %v", err)
+			}
 		}
 
 		return nil
@@ -58,22 +67,21 @@ func generateChangelog(release *domain.Release) string {
 	changelog.WriteString("# 变更日志\n\n")
 
 	// 添加当前版本
-	changelog.WriteString(fmt.Sprintf("## [%s] - %s\n\n", release.Version.Next.String(), time.Now().Format("2006-01-02")))
-
-	// 添加变更类型
-	for category, changes := range release.Changes {
-		if len(changes) == 0 {
-			continue
-		}
+	changelog.WriteString(fmt.Sprintf("## [%s] - %s\n\n", release.Version.NextString(), time.Now().Format("2006-01-02")))
 
+	// 添加变更类型，配置了 release.groups 时按分组列出
+	for _, section := range release.Sections(settings.NoteGroups()) {
 		// 转换类别名称
-		categoryName := getCategoryName(category)
+		title := section.Title
+		if section.Category != "" {
+			title = getCategoryName(section.Category)
+		}
 
 		// 添加类别标题
-		changelog.WriteString(fmt.Sprintf("### %s\n\n", categoryName))
+		changelog.WriteString(fmt.Sprintf("### %s\n\n", title))
 
 		// 添加变更列表
-		for _, change := range changes {
+		for _, change := range section.Changes {
 			changelog.WriteString(fmt.Sprintf("- %s\n", change.Subject))
 		}
 
@@ -101,6 +109,8 @@ func getCategoryName(category string) string {
 		return "测试相关"
 	case "chore":
 		return "构建过程或辅助工具的变动"
+	case "revert":
+		return "回滚"
 	case "breaking":
 		return "破坏性变更"
 	default:
@@ -110,4 +120,7 @@ func getCategoryName(category string) string {
 
 func init() {
 	rootCmd.AddCommand(changelogCmd)
+
+	// 命令特定选项
+	addComponentFlags(changelogCmd)
 }
@@ -2,11 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"net/url"
 	"strings"
-	"text/template"
 
-	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/fanny7d/semrel-gitlab/pkg/actions"
+	"github.com/fanny7d/semrel-gitlab/pkg/render"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +16,10 @@ var commitAndTagCmd = &cobra.Command{
 	Long: `提交并推送列出的文件。
 如果文件不包含任何更改，命令将失败。
 
+配置文件的 version.files 或 --version-files 中的版本文件会先更新为下一个版本，
+例如 package.json、Chart.yaml、pom.xml、pyproject.toml、Cargo.toml、VERSION
+和 Go 源文件中的 const Version，内容有变化的版本文件和列出的文件在同一个提交中提交。
+
 默认的提交消息模板包含 [skip ci]，
 以防止提交管道运行。你可以使用全局选项
 --bump-commit-tmpl 或环境变量 GSG_BUMP_COMMIT_TMPL
@@ -24,42 +28,25 @@ var commitAndTagCmd = &cobra.Command{
 为新提交创建标签和发布说明
 (查看 'release help tag' 获取更多详细信息)。`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// 获取全局选项
-		token := cmd.Flag("token").Value.String()
-		if token == "" {
-			return fmt.Errorf("token 是必需的")
-		}
+		// 获取命令选项
+		createTagPipeline, _ := cmd.Flags().GetBool("create-tag-pipeline")
 
-		apiURL := cmd.Flag("gl-api").Value.String()
-		if apiURL == "" {
-			return fmt.Errorf("gl-api 是必需的")
+		if len(args) == 0 && len(settings.VersionFiles) == 0 {
+			return fmt.Errorf("至少需要一个要提交的文件，或者通过 version.files 或 --version-files 指定版本文件")
 		}
-
-		project := cmd.Flag("ci-project-path").Value.String()
-		if project == "" {
-			return fmt.Errorf("ci-project-path 是必需的")
+		if err := requireGitLab(); err != nil {
+			return err
 		}
-
-		projectURLStr := cmd.Flag("ci-project-url").Value.String()
-		if projectURLStr == "" {
-			return fmt.Errorf("ci-project-url 是必需的")
+		if settings.GitPush {
+			return fmt.Errorf("新提交通过 GitLab API 创建，不在本地仓库中，不能和 --git-push 一起使用")
 		}
-
-		projectURL, err := url.Parse(projectURLStr)
-		if err != nil {
-			return fmt.Errorf("解析 project-url 失败: %v", err)
+		branch := settings.CI.CommitRefName
+		if branch == "" {
+			return fmt.Errorf("提交文件需要 ci-commit-ref-name")
 		}
 
-		skipSSLVerify, _ := cmd.Flags().GetBool("skip-ssl-verify")
-		patchTypes := strings.Split(cmd.Flag("patch-commit-types").Value.String(), ",")
-		minorTypes := strings.Split(cmd.Flag("minor-commit-types").Value.String(), ",")
-		tagPrefix := cmd.Flag("tag-prefix").Value.String()
-		branch := cmd.Flag("ci-commit-ref-name").Value.String()
-		commitTmpl := cmd.Flag("bump-commit-tmpl").Value.String()
-
-		// 创建服务
-		gitService := service.NewGitService(patchTypes, minorTypes, tagPrefix)
-		gitlabService, err := service.NewGitLabService(token, apiURL, project, projectURL, skipSSLVerify)
+		// 创建 Git 服务
+		gitService, err := newGitService()
 		if err != nil {
 			return err
 		}
@@ -75,42 +62,53 @@ var commitAndTagCmd = &cobra.Command{
 			return fmt.Errorf("提交日志中没有发现会改变版本的变更")
 		}
 
-		// 渲染提交消息
-		tmpl, err := template.New("commit").Parse(commitTmpl)
+		// 确定要提交的文件，版本文件在执行发布前才修改
+		files, err := commitFiles(release, args)
 		if err != nil {
-			return fmt.Errorf("解析提交消息模板失败: %v", err)
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("版本文件已经是版本 %s，没有要提交的更改", release.Version.NextString())
 		}
 
-		var message strings.Builder
-		err = tmpl.Execute(&message, map[string]string{
-			"tag": release.TagName,
-		})
+		// 渲染提交消息
+		message, err := render.BumpMessage(release.TagName, release.Version.NextString(), settings.BumpCommitTmpl)
 		if err != nil {
 			return fmt.Errorf("渲染提交消息失败: %v", err)
 		}
 
-		// 创建提交
-		if err := gitlabService.CreateCommit(branch, message.String(), args); err != nil {
+		// 渲染发布说明
+		if err := newRenderService("").RenderReleaseNote(release); err != nil {
 			return err
 		}
 
-		// 渲染发布说明
-		renderService := service.NewRenderService("")
-		if err := renderService.RenderReleaseNote(release); err != nil {
+		// 创建 GitLab 客户端
+		client, err := newGitLabClient()
+		if err != nil {
 			return err
 		}
 
-		// 创建标签
-		if err := gitlabService.CreateTag(release, branch); err != nil {
+		// 提交文件，并在新提交上创建标签和发布
+		commit := actions.NewCommit(client, settings.CI.ProjectPath, branch, message, files)
+		createTag, createRelease, err := tagAndReleaseActions(client, gitService, release, commit.CommitIDFunc())
+		if err != nil {
 			return err
 		}
+		actionList := []workflow.Action{commit, createTag, createRelease}
 
 		// 如果需要，创建管道
-		createTagPipeline, _ := cmd.Flags().GetBool("create-tag-pipeline")
-		if createTagPipeline && strings.Contains(message.String(), "[skip ci]") {
-			if err := gitlabService.CreatePipeline(branch); err != nil {
-				return err
-			}
+		if createTagPipeline && strings.Contains(message, "[skip ci]") {
+			actionList = append(actionList, actions.NewCreatePipeline(client, settings.CI.ProjectPath, createTag.TagFunc()))
+		}
+
+		// 更新版本文件并执行发布，失败时恢复版本文件
+		restore, err := updateReleaseFiles(release, nil, "")
+		if err != nil {
+			return err
+		}
+		applied, err := runWorkflow(cmd.Context(), actionList, release)
+		if err != nil || !applied {
+			return restoreOnError(err, restore)
 		}
 
 		fmt.Printf("已创建标签 %s\n", release.TagName)
@@ -124,4 +122,6 @@ func init() {
 	// 命令特定选项
 	commitAndTagCmd.Flags().Bool("create-tag-pipeline", false, "当标记的提交消息包含 [skip ci] 并且你想要执行标签管道时需要")
 	commitAndTagCmd.Flags().Bool("list-other-changes", false, "列出不影响版本控制的更改")
+	commitAndTagCmd.Flags().String("version-files", "", "逗号分隔的版本文件列表，根据文件名推断类型，替换配置文件中的 version.files")
+	commitAndTagCmd.Flags().String("release-as", "", "指定下一个版本号，例如 2.0.0，优先于提交中的 Release-As 脚注。必须大于当前版本")
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/config"
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/spf13/cobra"
+)
+
+// analysisTarget 是一次提交分析的对象：整个仓库或者 monorepo 中的一个组件
+type analysisTarget struct {
+	// component 是分析的组件，分析整个仓库时为 nil
+	component  *config.Component
+	gitService *service.GitService
+	release    *domain.Release
+}
+
+// name 返回组件名称，分析整个仓库时为空字符串
+func (t analysisTarget) name() string {
+	if t.component == nil {
+		return ""
+	}
+	return t.component.Name
+}
+
+// analyzeTargets 分析 --component 或 --all-components 选择的每个组件，
+// 没有选择组件时分析整个仓库
+func analyzeTargets() ([]analysisTarget, error) {
+	components, err := settings.SelectedComponents()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(components) == 0 {
+		gitService, err := newGitService()
+		if err != nil {
+			return nil, err
+		}
+		release, err := gitService.AnalyzeCommits()
+		if err != nil {
+			return nil, err
+		}
+		return []analysisTarget{{gitService: gitService, release: release}}, nil
+	}
+
+	if len(components) > 1 && settings.ReleaseAs != "" {
+		return nil, fmt.Errorf("同时处理多个组件时不能指定 --release-as")
+	}
+
+	targets := make([]analysisTarget, 0, len(components))
+	for i := range components {
+		component := &components[i]
+		gitService, err := newComponentGitService(*component)
+		if err != nil {
+			return nil, err
+		}
+		release, err := gitService.AnalyzeCommits()
+		if err != nil {
+			return nil, fmt.Errorf("分析组件 %s 失败: %v", component.Name, err)
+		}
+		targets = append(targets, analysisTarget{component: component, gitService: gitService, release: release})
+	}
+	return targets, nil
+}
+
+// multipleTargets 判断命令是否同时处理多个组件。
+// 只选择了一个组件时命令的输出与分析整个仓库时相同
+func multipleTargets(targets []analysisTarget) bool {
+	return settings.AllComponents || len(targets) > 1
+}
+
+// addComponentFlags 为命令添加选择 monorepo 组件的选项
+func addComponentFlags(cmd *cobra.Command) {
+	cmd.Flags().String("component", "", "逗号分隔的组件名称列表，只分析这些组件。组件在配置文件的 components 中定义")
+	cmd.Flags().Bool("all-components", false, "分析配置文件中定义的所有组件，只处理有变更的组件")
+	cmd.MarkFlagsMutuallyExclusive("component", "all-components")
+}
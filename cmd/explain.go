@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+)
+
+// explainFormats 是 --explain 支持的输出格式
+var explainFormats = []string{"text", "json"}
+
+// explainOutput 是 --explain=json 输出的一个分析对象
+type explainOutput struct {
+	// Component 是组件名称，分析整个仓库时省略
+	Component string `json:"component,omitempty"`
+	*domain.Explanation
+}
+
+// printExplanations 按 format 打印每个分析对象的版本说明。
+// json 格式在分析单个对象时输出一个 JSON 对象，同时处理多个组件时输出数组
+func printExplanations(w io.Writer, format string, targets []analysisTarget, multiple bool) error {
+	switch format {
+	case "text":
+		for i, target := range targets {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			if multiple {
+				fmt.Fprintf(w, "组件: %s\n", target.name())
+			}
+			writeExplanation(w, target.release.Explanation)
+		}
+		return nil
+	case "json":
+		outputs := make([]explainOutput, 0, len(targets))
+		for _, target := range targets {
+			outputs = append(outputs, explainOutput{Component: target.name(), Explanation: target.release.Explanation})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if multiple {
+			return enc.Encode(outputs)
+		}
+		return enc.Encode(outputs[0])
+	}
+	return fmt.Errorf("未知的说明格式 %q，可选值为 %s", format, strings.Join(explainFormats, ", "))
+}
+
+// writeExplanation 以文本形式打印基准标签、每个提交的解析结果、被忽略的提交和最终的版本决定
+func writeExplanation(w io.Writer, e *domain.Explanation) {
+	if e.BaseTag != "" {
+		fmt.Fprintf(w, "基准标签: %s (%s)\n", e.BaseTag, shortHash(e.BaseCommit))
+	} else {
+		fmt.Fprintln(w, "基准标签: 无，从 0.0.0 开始")
+	}
+
+	fmt.Fprintf(w, "\n分析的提交 (%d):\n", len(e.Commits))
+	for _, c := range e.Commits {
+		details := []string{"类型 " + orDash(c.Type)}
+		if c.Scope != "" {
+			details = append(details, "范围 "+c.Scope)
+		}
+		if c.Breaking {
+			details = append(details, "破坏性变更")
+		}
+		if c.ReleaseAs != "" {
+			details = append(details, "Release-As "+c.ReleaseAs)
+		}
+		fmt.Fprintf(w, "  %s %s\n      %s -> %s\n", shortHash(c.Hash), c.Header, strings.Join(details, ", "), c.Level)
+	}
+
+	if len(e.Ignored) > 0 {
+		fmt.Fprintf(w, "\n忽略的提交 (%d):\n", len(e.Ignored))
+		for _, c := range e.Ignored {
+			fmt.Fprintf(w, "  %s %s\n      %s\n", shortHash(c.Hash), c.Header, c.Reason.Description())
+		}
+	}
+
+	d := e.Decision
+	fmt.Fprintln(w, "\n决定:")
+	fmt.Fprintf(w, "  当前版本: %s\n", d.Current)
+	fmt.Fprintf(w, "  提交中最高的升级级别: %s\n", d.CommitLevel)
+	fmt.Fprintf(w, "  initial-development: %t, bump-patch: %t\n", d.InitialDevelopment, d.BumpPatch)
+	if d.Channel != "" {
+		fmt.Fprintf(w, "  发布渠道: %s\n", d.Channel)
+	}
+	if d.Maintenance != "" {
+		fmt.Fprintf(w, "  维护分支范围: %s\n", d.Maintenance)
+	}
+	for _, adjustment := range d.Adjustments {
+		fmt.Fprintf(w, "  调整: %s\n", adjustment)
+	}
+	fmt.Fprintf(w, "  应用的升级级别: %s\n", d.Level)
+	fmt.Fprintf(w, "  下一个版本: %s\n", d.Next)
+}
+
+// shortHash 返回提交哈希的前 7 位
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// orDash 在 s 为空时返回 "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,10 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
-	"github.com/blang/semver"
-	"github.com/fanny7d/semrel-gitlab/pkg/domain"
-	"github.com/fanny7d/semrel-gitlab/pkg/service"
 	"github.com/spf13/cobra"
 )
 
@@ -15,43 +14,70 @@ var nextVersionCmd = &cobra.Command{
 	Long: `分析提交消息并打印下一个版本号。
 
 此命令会遍历 HEAD 的父提交，收集未发布的更改，并与每个分支中遇到的第一个标签进行比较，
-以确定下一个版本的基准。`,
+以确定下一个版本的基准。
+
+使用 --all-components 或者选择多个组件时，每行打印一个有变更的组件的名称和下一个版本号，
+例如 "api 1.3.0"。
+
+使用 --explain 时不打印版本号，而是说明版本是怎样确定的：基准标签、每个参与计算的提交的类型、范围、
+破坏性变更标记和升级级别、被忽略的提交及原因，以及 initial-development、bump-patch 和 --release-as
+对最终版本的调整。--explain=json 以 JSON 格式输出相同的内容。
+
+--output 为 json、yaml 或 dotenv 时输出当前版本、下一个版本、升级级别、标签名称、发布说明和按类别分组的变更，
+没有变更时 changed 为 false，命令不会失败。`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 获取命令行参数
-		bumpPatch, _ := cmd.Flags().GetBool("bump-patch")
 		allowCurrent, _ := cmd.Flags().GetBool("allow-current")
-
-		// 创建 Git 服务
-		gitService := service.NewGitService(nil, nil, "")
+		explain, _ := cmd.Flags().GetString("explain")
+		if explain != "" && explain != "text" && explain != "json" {
+			return fmt.Errorf("未知的说明格式 %q，可选值为 %s", explain, strings.Join(explainFormats, ", "))
+		}
 
 		// 分析提交
-		release, err := gitService.AnalyzeCommits()
+		targets, err := analyzeTargets()
 		if err != nil {
 			return err
 		}
 
+		// 说明版本是怎样确定的，没有变更时也不视为错误
+		if explain != "" {
+			return printExplanations(os.Stdout, explain, targets, multipleTargets(targets))
+		}
+
+		if machineOutput() {
+			return printReleases(os.Stdout, targets, multipleTargets(targets))
+		}
+
+		// 同时处理多个组件时每行打印一个组件的名称和版本号，跳过没有变更的组件
+		if multipleTargets(targets) {
+			changed := false
+			for _, target := range targets {
+				release := target.release
+				switch {
+				case release.HasContent():
+					fmt.Printf("%s %s\n", target.name(), release.Version.NextString())
+					changed = true
+				case allowCurrent:
+					fmt.Printf("%s %s\n", target.name(), release.Version.CurrentString())
+				}
+			}
+			if !changed && !allowCurrent {
+				return fmt.Errorf("没有检测到更改")
+			}
+			return nil
+		}
+		release := targets[0].release
+
 		// 检查是否有更改
 		if !release.HasContent() {
 			if allowCurrent {
-				fmt.Println(release.Version.Current.String())
-				return nil
-			}
-			if bumpPatch {
-				release.Version.Bump(domain.BumpPatch)
-				fmt.Println(release.Version.Next.String())
+				fmt.Println(release.Version.CurrentString())
 				return nil
 			}
 			return fmt.Errorf("没有检测到更改")
 		}
 
-		// 确保在初始开发期间遵循版本规则
-		if initialDevelopment, _ := cmd.Flags().GetBool("initial-development"); initialDevelopment {
-			if release.Version.Next.Major > 0 {
-				release.Version.Next = semver.MustParse("0.1.0")
-			}
-		}
-
-		fmt.Println(release.Version.Next.String())
+		fmt.Println(release.Version.NextString())
 		return nil
 	},
 }
@@ -63,4 +89,8 @@ func init() {
 	nextVersionCmd.Flags().Bool("bump-patch", false, "当没有提交会触发版本更新时强制增加补丁版本")
 	nextVersionCmd.Flags().Bool("allow-current", false, "如果没有检测到更改，允许打印当前版本")
 	nextVersionCmd.MarkFlagsMutuallyExclusive("bump-patch", "allow-current")
+	nextVersionCmd.Flags().String("release-as", "", "指定下一个版本号，例如 2.0.0，优先于提交中的 Release-As 脚注。必须大于当前版本")
+	nextVersionCmd.Flags().String("explain", "", "说明下一个版本是怎样确定的。格式为 text 或 json，只写 --explain 时为 text")
+	nextVersionCmd.Flags().Lookup("explain").NoOptDefVal = "text"
+	addComponentFlags(nextVersionCmd)
 }
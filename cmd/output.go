@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix 是 dotenv 输出中变量名的前缀
+const envPrefix = "SEMREL_"
+
+// envNamePattern 匹配组件名称中不能出现在环境变量名中的字符
+var envNamePattern = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// releaseOutput 是 --output 为 json、yaml 或 dotenv 时输出的发布信息，字段名是稳定的接口
+type releaseOutput struct {
+	// Component 是组件名称，分析整个仓库时省略
+	Component      string `json:"component,omitempty" yaml:"component,omitempty"`
+	CurrentVersion string `json:"current_version" yaml:"current_version"`
+	NextVersion    string `json:"next_version" yaml:"next_version"`
+	BumpLevel      string `json:"bump_level" yaml:"bump_level"`
+	TagName        string `json:"tag_name" yaml:"tag_name"`
+	// Changed 表示是否有会改变版本的变更，为 false 时 NextVersion 等于 CurrentVersion
+	Changed      bool                      `json:"changed" yaml:"changed"`
+	ReleaseNotes string                    `json:"release_notes" yaml:"release_notes"`
+	Changes      map[string][]changeOutput `json:"changes" yaml:"changes"`
+}
+
+// changeOutput 是发布中的一个变更
+type changeOutput struct {
+	Hash     string `json:"hash" yaml:"hash"`
+	Type     string `json:"type" yaml:"type"`
+	Scope    string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Subject  string `json:"subject" yaml:"subject"`
+	Breaking bool   `json:"breaking" yaml:"breaking"`
+}
+
+// envVar 是 dotenv 输出中的一个变量
+type envVar struct {
+	name  string
+	value string
+}
+
+// machineOutput 判断是否使用机器可读的输出格式
+func machineOutput() bool {
+	return settings.Output != "" && settings.Output != "text"
+}
+
+// newReleaseOutput 渲染发布说明并返回发布的输出信息
+func newReleaseOutput(target analysisTarget) (releaseOutput, error) {
+	release := target.release
+	if err := newRenderService("").RenderReleaseNote(release); err != nil {
+		return releaseOutput{}, err
+	}
+
+	out := releaseOutput{
+		Component:      target.name(),
+		CurrentVersion: release.Version.CurrentString(),
+		NextVersion:    release.Version.NextString(),
+		BumpLevel:      release.Version.Level.String(),
+		TagName:        release.TagName,
+		Changed:        release.HasContent(),
+		ReleaseNotes:   release.Message,
+		Changes:        make(map[string][]changeOutput),
+	}
+	if !out.Changed {
+		out.NextVersion = out.CurrentVersion
+		out.BumpLevel = domain.NoBump.String()
+	}
+	for category, changes := range release.Changes {
+		for _, c := range changes {
+			out.Changes[category] = append(out.Changes[category], changeOutput{
+				Hash:     c.Hash,
+				Type:     string(c.Type),
+				Scope:    c.Scope,
+				Subject:  c.Subject,
+				Breaking: c.Breaking,
+			})
+		}
+	}
+	return out, nil
+}
+
+// env 返回发布的 dotenv 变量。同时处理多个组件时变量名包含组件名称，例如 SEMREL_API_NEXT_VERSION
+func (o releaseOutput) env(multiple bool) []envVar {
+	prefix := envPrefix
+	if multiple && o.Component != "" {
+		prefix += envNamePattern.ReplaceAllString(strings.ToUpper(o.Component), "_") + "_"
+	}
+	return []envVar{
+		{prefix + "CURRENT_VERSION", o.CurrentVersion},
+		{prefix + "NEXT_VERSION", o.NextVersion},
+		{prefix + "BUMP_LEVEL", o.BumpLevel},
+		{prefix + "TAG_NAME", o.TagName},
+		{prefix + "CHANGED", fmt.Sprint(o.Changed)},
+	}
+}
+
+// printReleases 按 --output 打印分析对象的发布信息。
+// 分析单个对象时输出一个对象，同时处理多个组件时输出数组，dotenv 格式输出带组件名称的变量
+func printReleases(w io.Writer, targets []analysisTarget, multiple bool) error {
+	outputs := make([]releaseOutput, 0, len(targets))
+	vars := make([]envVar, 0)
+	for _, target := range targets {
+		out, err := newReleaseOutput(target)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, out)
+		vars = append(vars, out.env(multiple)...)
+	}
+	if multiple {
+		return printOutput(w, outputs, vars)
+	}
+	return printOutput(w, outputs[0], vars)
+}
+
+// printOutput 按 --output 打印 value，dotenv 格式打印 vars
+func printOutput(w io.Writer, value interface{}, vars []envVar) error {
+	switch settings.Output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(value); err != nil {
+			return fmt.Errorf("输出 YAML 失败: %v", err)
+		}
+		return enc.Close()
+	case "dotenv":
+		// GitLab 的 dotenv 报告不支持引号和多行值，值中的换行被替换为空格
+		for _, v := range vars {
+			fmt.Fprintf(w, "%s=%s\n", v.name, strings.Join(strings.Fields(v.value), " "))
+		}
+		return nil
+	}
+	return fmt.Errorf("命令不支持输出格式 %q", settings.Output)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/actions"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote [标签]",
+	Short: "把预发布版本提升为正式版本",
+	Long: `把通过测试的预发布版本提升为正式版本，不需要新的提交。
+
+此命令将：
+1. 找到预发布版本标签，默认使用 CI_COMMIT_TAG，没有时使用 HEAD 上版本最高的预发布版本标签
+2. 去掉预发布版本标识和构建元数据得到正式版本，例如 v1.4.0-rc.3 提升为 v1.4.0
+3. 在预发布版本标签指向的提交上创建正式版本标签
+4. 在 GitLab 上创建发布，发布说明包含自上一个正式版本以来所有预发布版本中的变更
+
+正式版本标签已经存在时命令失败。组件的预发布版本使用 --tag-prefix 指定组件的标签前缀。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireGitLab(); err != nil {
+			return err
+		}
+
+		// 确定要提升的预发布版本标签
+		tagName := settings.CI.CommitTag
+		if len(args) > 0 {
+			tagName = args[0]
+		}
+
+		gitService, err := newGitService()
+		if err != nil {
+			return err
+		}
+		release, promotion, err := gitService.Promote(tagName)
+		if err != nil {
+			return err
+		}
+
+		// 创建 GitLab 客户端
+		client, err := newGitLabClient()
+		if err != nil {
+			return err
+		}
+
+		// 渲染发布说明
+		if err := newRenderService("").RenderReleaseNote(release); err != nil {
+			return err
+		}
+
+		// 在预发布版本的提交上创建标签和 GitLab 发布
+		createTag, createRelease, err := tagAndReleaseActions(client, gitService, release, actions.NewFuncOfString(promotion.Commit))
+		if err != nil {
+			return err
+		}
+		applied, err := runWorkflow(cmd.Context(), []workflow.Action{createTag, createRelease}, release)
+		if err != nil || !applied {
+			return err
+		}
+
+		fmt.Printf("已将 %s 提升为 %s 并发布到 GitLab\n", promotion.Tag, release.TagName)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+}
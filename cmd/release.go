@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/actions"
+	"github.com/fanny7d/semrel-gitlab/pkg/render"
+	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+var releaseCmd = &cobra.Command{
+	Use:   "release [文件...]",
+	Short: "分析提交并完成整个发布流程",
+	Long: `分析提交信息并在 GitLab 上完成整个发布流程。
+
+此命令将：
+1. 分析提交信息，确定下一个版本号
+2. 渲染发布说明
+3. 更新 version.files 或 --version-files 中的版本文件，可选地更新变更日志，并与列出的文件一起提交
+4. 创建标签和 GitLab 发布
+5. 上传 --files 匹配的文件并添加到发布
+
+所有 GitLab 操作按顺序执行，任何一步失败都会回滚已经完成的操作。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 获取命令选项
+		updateChangelog, _ := cmd.Flags().GetBool("update-changelog")
+		changelogFile, _ := cmd.Flags().GetString("changelog-file")
+		patterns, _ := cmd.Flags().GetStringArray("files")
+		createTagPipeline, _ := cmd.Flags().GetBool("create-tag-pipeline")
+
+		if err := requireGitLab(); err != nil {
+			return err
+		}
+
+		// 展开要上传的文件
+		assets, err := expandFiles(patterns)
+		if err != nil {
+			return err
+		}
+
+		// 分析提交
+		gitService, err := newGitService()
+		if err != nil {
+			return err
+		}
+		release, err := gitService.AnalyzeCommits()
+		if err != nil {
+			return err
+		}
+
+		// 检查是否有变更
+		if !release.HasContent() {
+			return fmt.Errorf("提交日志中没有发现会改变版本的变更")
+		}
+
+		// 渲染发布说明
+		renderService := newRenderService(changelogFile)
+		if err := renderService.RenderReleaseNote(release); err != nil {
+			return err
+		}
+
+		// 确定发布提交的文件，版本文件和变更日志在执行发布前才修改
+		files, err := commitFiles(release, args)
+		if err != nil {
+			return err
+		}
+		var changelog *service.RenderService
+		if updateChangelog {
+			if settings.DryRun {
+				fmt.Printf("变更日志 %s 将添加以下条目:\n\n%s\n", changelogFile, renderService.ChangelogEntry(release))
+			}
+			changelog = renderService
+			files = append(files, changelogFile)
+		}
+
+		// 创建 GitLab 客户端
+		client, err := newGitLabClient()
+		if err != nil {
+			return err
+		}
+		project := settings.CI.ProjectPath
+
+		// 构建操作序列
+		actionList := make([]workflow.Action, 0)
+
+		var refFunc func() string
+		message := ""
+		if len(files) > 0 {
+			if settings.GitPush {
+				return fmt.Errorf("发布提交通过 GitLab API 创建，不在本地仓库中，不能和 --git-push 一起使用")
+			}
+			if settings.CI.CommitRefName == "" {
+				return fmt.Errorf("提交文件需要 ci-commit-ref-name")
+			}
+			message, err = render.BumpMessage(release.TagName, release.Version.NextString(), settings.BumpCommitTmpl)
+			if err != nil {
+				return fmt.Errorf("渲染提交消息失败: %v", err)
+			}
+			commit := actions.NewCommit(client, project, settings.CI.CommitRefName, message, files)
+			actionList = append(actionList, commit)
+			refFunc = commit.CommitIDFunc()
+		} else {
+			ref, err := tagRef()
+			if err != nil {
+				return err
+			}
+			refFunc = actions.NewFuncOfString(ref)
+		}
+
+		createTag, createRelease, err := tagAndReleaseActions(client, gitService, release, refFunc)
+		if err != nil {
+			return err
+		}
+		actionList = append(actionList, createTag, createRelease)
+
+		if len(assets) > 0 {
+			projectURL, err := settings.ProjectURL()
+			if err != nil {
+				return err
+			}
+			for _, asset := range assets {
+				upload := actions.NewUpload(client, project, projectURL, asset)
+				link := actions.NewCreateReleaseLink(client, project, createRelease.TagFunc(), filepath.Base(asset), upload.LinkURLFunc())
+				actionList = append(actionList, upload, link)
+			}
+		}
+
+		if createTagPipeline && strings.Contains(message, "[skip ci]") {
+			actionList = append(actionList, actions.NewCreatePipeline(client, project, createTag.TagFunc()))
+		}
+
+		// 更新版本文件和变更日志并执行发布，失败时恢复这些文件
+		restore, err := updateReleaseFiles(release, changelog, changelogFile)
+		if err != nil {
+			return err
+		}
+		applied, err := runWorkflow(cmd.Context(), actionList, release)
+		if err != nil || !applied {
+			return restoreOnError(err, restore)
+		}
+
+		fmt.Printf("已发布 %s\n", release.TagName)
+		return nil
+	},
+}
+
+// expandFiles 展开 glob 模式，任何模式没有匹配到文件都视为错误
+func expandFiles(patterns []string) ([]string, error) {
+	files := make([]string, 0)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的文件模式 %s: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("没有文件匹配 %s", pattern)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func init() {
+	rootCmd.AddCommand(releaseCmd)
+
+	// 命令特定选项
+	releaseCmd.Flags().Bool("update-changelog", false, "更新变更日志并包含在发布提交中")
+	releaseCmd.Flags().String("changelog-file", "CHANGELOG.md", "变更日志文件")
+	releaseCmd.Flags().StringArray("files", nil, "要上传并添加到发布的文件，支持 glob 模式，可以多次指定")
+	releaseCmd.Flags().String("version-files", "", "逗号分隔的版本文件列表，根据文件名推断类型，替换配置文件中的 version.files")
+	releaseCmd.Flags().Bool("create-tag-pipeline", false, "当发布提交消息包含 [skip ci] 并且你想要执行标签管道时需要")
+}
@@ -1,14 +1,23 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/fanny7d/semrel-gitlab/pkg/config"
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	"github.com/spf13/cobra"
 )
 
 var (
 	version = "DEV"
+
+	// settings 是所有命令共用的设置，在命令执行前加载
+	settings *config.Settings
 )
 
 var rootCmd = &cobra.Command{
@@ -27,20 +36,16 @@ var rootCmd = &cobra.Command{
 - 预发布版本支持`,
 	Version: version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// 验证必要的环境变量
-		if token, _ := cmd.Flags().GetString("token"); token == "" {
-			return fmt.Errorf("必须提供 GitLab 访问令牌")
-		}
-
-		// 验证 CI 环境变量
-		if os.Getenv("CI_PROJECT_PATH") == "" {
-			return fmt.Errorf("必须提供 CI_PROJECT_PATH 环境变量")
+		// 合并配置文件、环境变量和命令行选项
+		var err error
+		settings, err = config.FromFlags(cmd.Flags(), os.LookupEnv)
+		if err != nil {
+			return err
 		}
-
-		if os.Getenv("CI_COMMIT_SHA") == "" {
-			return fmt.Errorf("必须提供 CI_COMMIT_SHA 环境变量")
+		// 预览模式不访问 GitLab API，不需要访问令牌
+		if settings.DryRun {
+			return cmd.Flags().SetAnnotation("token", cobra.BashCompOneRequiredFlag, []string{"false"})
 		}
-
 		return nil
 	},
 }
@@ -85,7 +90,11 @@ func Execute() {
 使用 "{{.CommandPath}} [命令] --help" 获取更多关于命令的信息。{{end}}
 `)
 
-	if err := rootCmd.Execute(); err != nil {
+	// 收到 SIGINT 或 SIGTERM（例如 CI 作业被取消或超时）时中断正在执行的操作并回滚
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -93,19 +102,41 @@ func Execute() {
 
 func init() {
 	// 全局选项
+	rootCmd.PersistentFlags().String("config", "", "配置文件路径。默认依次查找当前目录和用户主目录下的 "+config.FileName)
+	rootCmd.PersistentFlags().StringP("output", "o", "text", "只读命令的输出格式: text、json、yaml 或 dotenv。dotenv 可以直接作为 GitLab 的 artifacts:reports:dotenv")
+	rootCmd.PersistentFlags().Bool("dry-run", false, "只打印将要执行的操作，不修改 GitLab 中的数据")
+	rootCmd.PersistentFlags().Bool("git-push", false, "在本地仓库创建标签并通过 git push 推送到 ci-project-url，而不是通过 GitLab API 创建")
+	rootCmd.PersistentFlags().String("sign-key-file", "", "为标签签名的 OpenPGP 或 SSH 私钥文件，需要 --git-push")
+	rootCmd.PersistentFlags().String("sign-key", "", "为标签签名的私钥内容，建议通过 GSG_SIGN_KEY 环境变量设置")
+	rootCmd.PersistentFlags().String("sign-key-passphrase", "", "加密私钥的密码，建议通过 GSG_SIGN_KEY_PASSPHRASE 环境变量设置")
+	rootCmd.PersistentFlags().String("journal", ".semrel-journal.json", "记录已完成操作的日志文件，中断后再次运行时从日志继续。设置为空字符串时不记录")
+	rootCmd.PersistentFlags().Bool("rollback", false, "回滚日志文件中记录的已完成操作，而不是继续执行")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "执行 GitLab 操作的总时间限制，超时后中断并回滚。0 表示不限制")
+	rootCmd.PersistentFlags().Int("retries", workflow.DefaultRetryPolicy.MaxRetries, "GitLab 操作因为限流、超时或服务器错误失败后的最大重试次数")
+	rootCmd.PersistentFlags().Duration("retry-max-delay", workflow.DefaultRetryPolicy.MaxDelay, "两次重试之间的最长等待时间。Retry-After 超过此时间时不再重试")
 	rootCmd.PersistentFlags().StringP("token", "t", "", "GitLab 私有令牌 (必需)")
-	rootCmd.PersistentFlags().String("gl-api", os.Getenv("CI_API_V4_URL"), "GitLab API URL。如果未定义，则使用 CI_API_V4_URL 环境变量")
+	rootCmd.PersistentFlags().String("gl-api", "", "GitLab API URL。如果未定义，则使用 CI_API_V4_URL 环境变量")
 	rootCmd.PersistentFlags().Bool("skip-ssl-verify", false, "不验证 GitLab API 的 CA 证书")
 	rootCmd.PersistentFlags().String("patch-commit-types", "fix,refactor,perf,docs,style,test", "逗号分隔的提交消息类型列表，表示补丁版本更新")
 	rootCmd.PersistentFlags().String("minor-commit-types", "feat", "逗号分隔的提交消息类型列表，表示次要版本更新")
 	rootCmd.PersistentFlags().Bool("initial-development", true, "当你准备发布 1.0.0 时设置为 false，如果版本已经 >= 1.0.0 则忽略")
 	rootCmd.PersistentFlags().Bool("bump-patch", false, "当没有提交会触发版本更新时强制增加补丁版本")
-	rootCmd.PersistentFlags().String("release-branches", "main,master", "逗号分隔的分支名称列表")
+	rootCmd.PersistentFlags().String("convention", string(domain.ConventionConventional), "提交消息遵循的约定: conventional、angular、gitmoji、bracket、jira 或 regex。regex 的正则表达式在配置文件中设置")
+	rootCmd.PersistentFlags().String("merge-strategy", string(domain.MergeTitle), "分析合并提交的方式。title 使用符合规范的合并请求标题代表被合并的提交，branch 分析被合并分支上的每个提交")
+	rootCmd.PersistentFlags().String("release-branches", "main,master", "逗号分隔的发布正式版本的分支名称列表。没有在配置文件中设置 channels 时使用")
+	rootCmd.PersistentFlags().String("maintenance-branches", "*.x,release/*.x", "逗号分隔的维护分支通配符。维护分支名称以 <major>.x 或 <major>.<minor>.x 结尾，只能发布该范围内的版本")
+	rootCmd.PersistentFlags().String("scheme", "semver", "版本号方案: semver 或 calver")
+	rootCmd.PersistentFlags().String("calver-format", domain.CalVerFormats[0], "calver 方案的日历版本格式，例如 YYYY.MM.MICRO 或 YY.0M.MICRO")
 	rootCmd.PersistentFlags().String("tag-prefix", "v", "版本标签使用的前缀")
 	rootCmd.PersistentFlags().String("bump-commit-tmpl", "chore: 版本更新为 {{tag}} [skip ci]", "版本更新提交消息的模板")
-	rootCmd.PersistentFlags().String("pre-tmpl", "", "预发布版本模板。逗号分隔的 ID 模板列表")
+	rootCmd.PersistentFlags().String("pre-tmpl", "", "预发布版本模板。逗号分隔的 ID 模板列表，位于发布渠道的标识之后，默认为 {{ seq }}")
 	rootCmd.PersistentFlags().String("build-tmpl", "", "构建元数据模板。逗号分隔的 ID 模板列表")
 
-	// 从环境变量中读取默认值
+	// 由 Gitlab CI 自动填充的选项
+	rootCmd.PersistentFlags().String("ci-project-path", "", "项目路径。如果未定义，则使用 CI_PROJECT_PATH 环境变量")
+	rootCmd.PersistentFlags().String("ci-project-url", "", "项目 URL。如果未定义，则使用 CI_PROJECT_URL 环境变量")
+	rootCmd.PersistentFlags().String("ci-commit-ref-name", "", "分支或标签名称。如果未定义，则使用 CI_COMMIT_REF_NAME 环境变量")
+	rootCmd.PersistentFlags().String("ci-commit-sha", "", "提交哈希。如果未定义，则使用 CI_COMMIT_SHA 环境变量")
+
 	rootCmd.PersistentFlags().SetAnnotation("token", cobra.BashCompOneRequiredFlag, []string{"true"})
 }
@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
-	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/fanny7d/semrel-gitlab/pkg/actions"
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	"github.com/spf13/cobra"
 )
 
@@ -18,58 +19,88 @@ var tagCmd = &cobra.Command{
 2. 确定下一个版本号
 3. 创建 Git 标签
 4. 在 GitLab 上创建发布
-5. 添加发布说明和下载链接`,
+5. 添加发布说明和下载链接
+
+标签和发布通过 GitLab API 创建，创建发布失败时会删除已经创建的标签。
+使用 --git-push 时标签在本地仓库创建并推送到远程仓库，推送成功后才会创建发布，
+推送失败时删除本地标签，创建发布失败时删除远程和本地的标签。
+
+使用 --all-components 或者选择多个组件时，为每个有变更的组件创建各自的标签和发布，
+任何一个操作失败时回滚所有组件已经完成的操作。`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 获取命令选项
 		listOtherChanges, _ := cmd.Flags().GetBool("list-other-changes")
-		ciCommitTag, _ := cmd.Flags().GetString("ci-commit-tag")
-
-		// 获取全局选项
-		patchTypes := strings.Split(cmd.Flag("patch-commit-types").Value.String(), ",")
-		minorTypes := strings.Split(cmd.Flag("minor-commit-types").Value.String(), ",")
-		tagPrefix := cmd.Flag("tag-prefix").Value.String()
-		token, _ := cmd.Flags().GetString("token")
-		glAPI, _ := cmd.Flags().GetString("gl-api")
-		skipSSLVerify, _ := cmd.Flags().GetBool("skip-ssl-verify")
-		ciProjectPath, _ := cmd.Flags().GetString("ci-project-path")
 
-		// 创建 Git 服务
-		gitService := service.NewGitService(patchTypes, minorTypes, tagPrefix)
+		if err := requireGitLab(); err != nil {
+			return err
+		}
 
 		// 分析提交
-		release, err := gitService.AnalyzeCommits()
+		targets, err := analyzeTargets()
 		if err != nil {
 			return err
 		}
-
-		// 检查是否有变更
-		if !release.HasContent() && !listOtherChanges {
-			return fmt.Errorf("提交日志中没有发现会改变版本的变更")
+		multiple := multipleTargets(targets)
+		if multiple && settings.CI.CommitTag != "" {
+			return fmt.Errorf("同时为多个组件创建标签时不能指定 --ci-commit-tag")
 		}
 
 		// 创建 GitLab 客户端
-		client, err := service.NewGitLabClient(token, glAPI, skipSSLVerify)
+		client, err := newGitLabClient()
 		if err != nil {
-			return fmt.Errorf("创建 GitLab 客户端失败: %v", err)
+			return err
 		}
 
-		// 创建标签
-		tagName := ciCommitTag
-		if tagName == "" {
-			tagName = release.TagName
+		ref, err := tagRef()
+		if err != nil {
+			return err
 		}
 
-		// 创建 Git 标签
-		if err := gitService.CreateTag(tagName); err != nil {
-			return fmt.Errorf("创建 Git 标签失败: %v", err)
+		actionList := make([]workflow.Action, 0, 2*len(targets))
+		releases := make([]*domain.Release, 0, len(targets))
+		for _, target := range targets {
+			release := target.release
+
+			// 检查是否有变更，同时处理多个组件时跳过没有变更的组件
+			if !release.HasContent() {
+				if multiple {
+					continue
+				}
+				if !listOtherChanges {
+					return fmt.Errorf("提交日志中没有发现会改变版本的变更")
+				}
+			}
+
+			// 确定标签名称
+			if settings.CI.CommitTag != "" {
+				release.TagName = settings.CI.CommitTag
+			}
+
+			// 渲染发布说明
+			if err := newRenderService("").RenderReleaseNote(release); err != nil {
+				return err
+			}
+
+			// 创建标签和 GitLab 发布
+			createTag, createRelease, err := tagAndReleaseActions(client, target.gitService, release, actions.NewFuncOfString(ref))
+			if err != nil {
+				return err
+			}
+			actionList = append(actionList, createTag, createRelease)
+			releases = append(releases, release)
+		}
+		if len(releases) == 0 {
+			return fmt.Errorf("提交日志中没有发现会改变版本的变更")
 		}
 
-		// 创建 GitLab 发布
-		if err := client.CreateRelease(ciProjectPath, tagName, release); err != nil {
-			return fmt.Errorf("创建 GitLab 发布失败: %v", err)
+		applied, err := runWorkflow(cmd.Context(), actionList, releases...)
+		if err != nil || !applied {
+			return err
 		}
 
-		fmt.Printf("已创建标签 %s 并发布到 GitLab\n", tagName)
+		for _, release := range releases {
+			fmt.Printf("已创建标签 %s 并发布到 GitLab\n", release.TagName)
+		}
 		return nil
 	},
 }
@@ -79,4 +110,7 @@ func init() {
 
 	// 命令特定选项
 	tagCmd.Flags().Bool("list-other-changes", false, "列出不影响版本控制的更改")
+	tagCmd.Flags().String("ci-commit-tag", "", "要创建的标签名称，默认根据下一个版本生成")
+	tagCmd.Flags().String("release-as", "", "指定下一个版本号，例如 2.0.0，优先于提交中的 Release-As 脚注。必须大于当前版本")
+	addComponentFlags(tagCmd)
 }
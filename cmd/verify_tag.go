@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/spf13/cobra"
+)
+
+var verifyTagCmd = &cobra.Command{
+	Use:   "verify-tag [标签]",
+	Short: "检查发布标签的签名",
+	Long: `检查本地仓库中发布标签的 OpenPGP 或 SSH 签名。
+
+--keyring 指定受信任的公钥：ASCII armor 格式的 OpenPGP 公钥环，
+或者 SSH 的 allowed signers、authorized_keys 格式的公钥列表。
+没有指定标签时检查 CI_COMMIT_TAG。签名无效、密钥不受信任或者标签没有签名时命令失败。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 获取命令选项
+		keyring, _ := cmd.Flags().GetString("keyring")
+
+		tag := os.Getenv("CI_COMMIT_TAG")
+		if len(args) > 0 {
+			tag = args[0]
+		}
+		if tag == "" {
+			return fmt.Errorf("需要指定标签或者 CI_COMMIT_TAG")
+		}
+
+		trusted, err := os.ReadFile(keyring)
+		if err != nil {
+			return fmt.Errorf("读取受信任的公钥失败: %v", err)
+		}
+
+		gitService := service.NewGitService(settings.PatchTypes, settings.MinorTypes, settings.TagPrefix)
+		signer, err := gitService.VerifyTag(tag, trusted)
+		if err != nil {
+			return err
+		}
+
+		if machineOutput() {
+			return printOutput(os.Stdout, verifyTagOutput{Tag: tag, Signer: signer, Valid: true}, []envVar{
+				{envPrefix + "VERIFIED_TAG", tag},
+				{envPrefix + "TAG_SIGNER", signer},
+			})
+		}
+		fmt.Printf("标签 %s 的签名有效，签名者: %s\n", tag, signer)
+		return nil
+	},
+}
+
+// verifyTagOutput 是 verify-tag 在 --output 为 json、yaml 或 dotenv 时的输出。
+// 签名无效时命令失败，不输出结果
+type verifyTagOutput struct {
+	Tag    string `json:"tag" yaml:"tag"`
+	Signer string `json:"signer" yaml:"signer"`
+	Valid  bool   `json:"valid" yaml:"valid"`
+}
+
+func init() {
+	rootCmd.AddCommand(verifyTagCmd)
+
+	// 命令特定选项
+	verifyTagCmd.Flags().String("keyring", "", "受信任的 OpenPGP 公钥环或 SSH 公钥列表文件")
+	verifyTagCmd.MarkFlagRequired("keyring")
+}
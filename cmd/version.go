@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -17,8 +18,12 @@ var versionCmd = &cobra.Command{
 - 构建时间
 - Go 版本
 - 操作系统/架构`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if machineOutput() {
+			return printOutput(os.Stdout, map[string]string{"version": version}, []envVar{{envPrefix + "TOOL_VERSION", version}})
+		}
 		fmt.Printf("semrel-gitlab 版本 %s\n", version)
+		return nil
 	},
 }
 
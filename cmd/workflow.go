@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/actions"
+	"github.com/fanny7d/semrel-gitlab/pkg/config"
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/fanny7d/semrel-gitlab/pkg/gitlabutil"
+	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// tagRef 返回新标签指向的提交，优先使用 CI_COMMIT_SHA
+func tagRef() (string, error) {
+	if settings.CI.CommitSHA != "" {
+		return settings.CI.CommitSHA, nil
+	}
+	if settings.CI.CommitRefName != "" {
+		return settings.CI.CommitRefName, nil
+	}
+	return "", fmt.Errorf("创建标签需要 ci-commit-sha 或 ci-commit-ref-name")
+}
+
+// requireGitLab 检查访问 GitLab API 所需的设置。预览模式不访问 GitLab API，不需要这些设置
+func requireGitLab() error {
+	if settings.DryRun {
+		return nil
+	}
+	return settings.RequireGitLab()
+}
+
+// newGitLabClient 创建执行操作使用的 GitLab 客户端。
+// 预览模式下操作不会执行，没有访问令牌时创建不带令牌的客户端，只用于生成执行计划
+func newGitLabClient() (*gitlab.Client, error) {
+	if settings.DryRun && settings.Token == "" {
+		return gitlab.NewClient("")
+	}
+	client, err := gitlabutil.NewClient(settings.Token, settings.APIURL, settings.SkipSSLVerify)
+	if err != nil {
+		return nil, fmt.Errorf("创建 GitLab 客户端失败: %v", err)
+	}
+	return client, nil
+}
+
+// newGitService 创建分析整个仓库的提交历史使用的 Git 服务
+func newGitService() (*service.GitService, error) {
+	return configureGitService(service.NewGitService(settings.PatchTypes, settings.MinorTypes, settings.TagPrefix))
+}
+
+// newComponentGitService 创建分析 monorepo 组件的 Git 服务，只分析修改了组件路径的提交
+func newComponentGitService(component config.Component) (*service.GitService, error) {
+	gitService := service.NewGitService(component.PatchTypes, component.MinorTypes, component.TagPrefix)
+	gitService.SetPaths(component.Paths)
+	return configureGitService(gitService)
+}
+
+// configureGitService 设置提交约定、忽略的提交类型、版本号方案、版本计算策略、--release-as、发布渠道和合并提交的分析方式。
+// 按合并请求标题分析合并提交时，如果设置了 GitLab 访问令牌、API URL 和项目路径，
+// 通过 GitLab API 查询合并提交消息中没有的合并请求标题；预览模式不访问 GitLab API，不查询合并请求标题。
+func configureGitService(gitService *service.GitService) (*service.GitService, error) {
+	parser, err := settings.CommitParser()
+	if err != nil {
+		return nil, err
+	}
+	gitService.SetParser(parser)
+	gitService.SetIgnoreTypes(settings.IgnoreTypes)
+	scheme, err := settings.VersionScheme()
+	if err != nil {
+		return nil, err
+	}
+	gitService.SetScheme(scheme)
+	gitService.SetReleaseAs(settings.ReleaseAs)
+	gitService.SetBumpOptions(settings.BumpOptions())
+	gitService.SetMergeStrategy(settings.MergeStrategy)
+	if err := configureChannel(gitService); err != nil {
+		return nil, err
+	}
+	if settings.MergeStrategy == domain.MergeTitle && !settings.DryRun && settings.Token != "" && settings.APIURL != "" && settings.CI.ProjectPath != "" {
+		client, err := gitlabutil.NewClient(settings.Token, settings.APIURL, settings.SkipSSLVerify)
+		if err != nil {
+			return nil, err
+		}
+		gitService.SetMergeRequests(service.NewGitLabMergeRequests(client, settings.CI.ProjectPath))
+	}
+	return gitService, nil
+}
+
+// configureChannel 根据当前分支选择维护分支的版本范围或发布渠道，并设置预发布版本和构建元数据的模板。
+// 分支来自 CI_COMMIT_REF_NAME，不在 CI 中时使用本地仓库检出的分支。
+// 标签流水线和 HEAD 不指向分支时不使用发布渠道
+func configureChannel(gitService *service.GitService) error {
+	gitService.SetVersionTemplates(settings.PreTmpl, settings.BuildTmpl)
+	if settings.CI.TagPipeline {
+		return nil
+	}
+	branch := settings.CI.CommitRefName
+	if branch == "" {
+		var err error
+		if branch, err = gitService.CurrentBranch(); err != nil {
+			return err
+		}
+	}
+	if branch == "" {
+		return nil
+	}
+	// 维护分支发布分支名称中的版本范围内的正式版本，优先于发布渠道
+	maintenance, ok, err := settings.Maintenance(branch)
+	if err != nil {
+		return err
+	}
+	if ok {
+		gitService.SetMaintenance(maintenance)
+		gitService.SetChannel(domain.Channel{Name: branch, Branches: []string{branch}}, branch)
+		return nil
+	}
+	channel, err := settings.Channel(branch)
+	if err != nil {
+		return err
+	}
+	gitService.SetChannel(channel, branch)
+	return nil
+}
+
+// newRenderService 创建渲染发布说明和变更日志使用的渲染服务，
+// 提交中引用的议题和合并请求渲染为指向 ci-project-url 的链接，模板、作者、提交链接和分组来自配置文件的 release
+func newRenderService(changelogFile string) *service.RenderService {
+	renderService := service.NewRenderService(changelogFile)
+	renderService.SetProject(settings.CI.ProjectURL, settings.CI.ProjectPath)
+	renderService.SetOptions(service.ReleaseNoteOptions{
+		Template:       settings.Release.Template,
+		IncludeAuthors: settings.Release.IncludeAuthors,
+		IncludeLinks:   settings.Release.IncludeLinks,
+		Groups:         settings.NoteGroups(),
+	})
+	return renderService
+}
+
+// commitFiles 检查版本文件，返回更新为下一个版本后内容会变化的版本文件和 files 中的其他文件，作为发布提交的文件。
+// 只检查不写入，版本文件由 updateReleaseFiles 在执行发布前更新
+func commitFiles(release *domain.Release, files []string) ([]string, error) {
+	changed, err := service.UpdateVersionFiles(".", settings.VersionFiles, release.Version.NextString(), true)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !slices.Contains(changed, file) {
+			changed = append(changed, file)
+		}
+	}
+	return changed, nil
+}
+
+// updateReleaseFiles 在执行发布前把版本文件更新为下一个版本，changelog 不为 nil 时在变更日志 changelogFile 中添加条目。
+// 返回的 restore 把这些文件恢复为修改前的内容，发布失败时调用。
+// 预览模式和 --rollback 不修改文件
+func updateReleaseFiles(release *domain.Release, changelog *service.RenderService, changelogFile string) (restore func() error, err error) {
+	if settings.DryRun || settings.Rollback {
+		return func() error { return nil }, nil
+	}
+	paths := make([]string, 0, len(settings.VersionFiles)+1)
+	for _, f := range settings.VersionFiles {
+		paths = append(paths, filepath.FromSlash(f.Path))
+	}
+	if changelog != nil {
+		paths = append(paths, changelogFile)
+	}
+	snapshot, err := service.SnapshotFiles(paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = service.UpdateVersionFiles(".", settings.VersionFiles, release.Version.NextString(), false); err == nil && changelog != nil {
+		err = changelog.UpdateChangelog(release)
+	}
+	if err != nil {
+		if rerr := snapshot.Restore(); rerr != nil {
+			return nil, fmt.Errorf("%v，%v", err, rerr)
+		}
+		return nil, err
+	}
+	return snapshot.Restore, nil
+}
+
+// restoreOnError 在发布失败时调用 restore 恢复 updateReleaseFiles 修改的文件，返回发布的错误
+func restoreOnError(err error, restore func() error) error {
+	if err == nil {
+		return nil
+	}
+	if rerr := restore(); rerr != nil {
+		return fmt.Errorf("%v，%v", err, rerr)
+	}
+	return err
+}
+
+// tagAction 是创建标签的操作，通过 GitLab API 或者 git push 创建
+type tagAction interface {
+	workflow.Action
+	TagFunc() func() string
+}
+
+// tagAndReleaseActions 返回在 refFunc 指向的提交上创建标签和 GitLab 发布的操作。
+// 使用 --git-push 时标签在本地仓库创建并推送，refFunc 必须指向本地仓库中的提交，
+// 设置了签名私钥时创建签名标签。
+// release.Message 需要预先渲染，作为发布说明。
+func tagAndReleaseActions(client *gitlab.Client, gitService *service.GitService, release *domain.Release, refFunc func() string) (tagAction, *actions.CreateRelease, error) {
+	project := settings.CI.ProjectPath
+	message := fmt.Sprintf("Release %s", release.TagName)
+	var createTag tagAction
+	if settings.GitPush {
+		signer, err := tagSigner()
+		if err != nil {
+			return nil, nil, err
+		}
+		gitService.SetSigner(signer)
+		gitService.SetTagger(os.Getenv("GITLAB_USER_NAME"), os.Getenv("GITLAB_USER_EMAIL"))
+		createTag = actions.NewPushTag(gitService, pushOptions(), refFunc, release.TagName, message)
+	} else {
+		if settings.SignKey != "" || settings.SignKeyFile != "" {
+			return nil, nil, fmt.Errorf("GitLab API 不能创建签名标签，签名标签需要 --git-push")
+		}
+		createTag = actions.NewCreateTag(client, project, refFunc, release.TagName, message, false)
+	}
+	createRelease := actions.NewCreateRelease(client, project, createTag.TagFunc(), release.Version.NextString(), release.Message)
+	return createTag, createRelease, nil
+}
+
+// tagSigner 根据 --sign-key 或 --sign-key-file 创建标签签名器，没有设置私钥时返回 nil
+func tagSigner() (service.TagSigner, error) {
+	key := []byte(settings.SignKey)
+	if len(key) == 0 && settings.SignKeyFile != "" {
+		var err error
+		if key, err = os.ReadFile(settings.SignKeyFile); err != nil {
+			return nil, fmt.Errorf("读取签名私钥失败: %v", err)
+		}
+	}
+	if len(key) == 0 {
+		return nil, nil
+	}
+	return service.NewTagSigner(key, settings.SignKeyPassphrase)
+}
+
+// pushOptions 返回推送标签使用的远程仓库和凭据。
+// 使用 CI_JOB_TOKEN 作为令牌时用户名为 gitlab-ci-token，其他访问令牌使用 oauth2
+func pushOptions() service.PushOptions {
+	opts := service.PushOptions{
+		Username: "oauth2",
+		Password: settings.Token,
+	}
+	if jobToken := os.Getenv("CI_JOB_TOKEN"); jobToken != "" && jobToken == settings.Token {
+		opts.Username = "gitlab-ci-token"
+	}
+	if settings.CI.ProjectURL != "" {
+		opts.RemoteURL = strings.TrimSuffix(settings.CI.ProjectURL, "/") + ".git"
+	}
+	return opts
+}
+
+// printPlan 在预览模式下打印每个发布的信息和操作序列的执行计划
+func printPlan(actionList []workflow.Action, releases ...*domain.Release) error {
+	fmt.Println("预览模式，不会修改 GitLab 中的数据")
+	for _, release := range releases {
+		fmt.Printf("\n当前版本: %s\n", release.Version.CurrentString())
+		fmt.Printf("下一个版本: %s (%s)\n", release.Version.NextString(), release.Version.Level)
+		if release.Version.ReleaseAs != nil {
+			fmt.Printf("版本由 %s 指定\n", release.Version.ReleaseAsSource)
+		}
+		fmt.Printf("标签: %s\n", release.TagName)
+	}
+	fmt.Println("\n执行计划:")
+	return workflow.Plan(os.Stdout, actionList)
+}
+
+// runWorkflow 执行操作序列，返回操作是否已经执行。
+// 预览模式下打印 releases 的版本信息和执行计划；指定了日志文件时从中断处继续，或者按 --rollback 回滚日志中记录的操作。
+// ctx 被取消或者超过 --timeout 时中断正在执行的操作并回滚。
+func runWorkflow(ctx context.Context, actionList []workflow.Action, releases ...*domain.Release) (bool, error) {
+	if settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.Timeout)
+		defer cancel()
+	}
+	switch {
+	case settings.DryRun:
+		return false, printPlan(actionList, releases...)
+	case settings.Rollback:
+		if err := workflow.RollbackJournal(ctx, actionList, settings.Journal); err != nil {
+			return false, err
+		}
+		fmt.Printf("已回滚日志 %s 中记录的操作\n", settings.Journal)
+		return false, nil
+	case settings.Journal != "":
+		return true, workflow.ApplyWithJournal(ctx, actionList, settings.Journal, settings.RetryPolicy())
+	default:
+		return true, workflow.ApplyWithPolicy(ctx, actionList, settings.RetryPolicy())
+	}
+}
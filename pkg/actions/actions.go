@@ -1,8 +1,10 @@
 package actions
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"strings"
 
 	"github.com/fanny7d/semrel-gitlab/pkg/gitlabutil"
 	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
@@ -20,6 +22,14 @@ func NewFuncOfString(s string) func() string {
 	}
 }
 
+// orPending 在值尚未确定时返回 workflow.Pending
+func orPending(s string) string {
+	if s == "" {
+		return workflow.Pending
+	}
+	return s
+}
+
 // CreateTagOptions GitLab 创建标签 API 的选项
 // 参考: https://docs.gitlab.com/ee/api/tags.html#create-a-new-tag
 type CreateTagOptions struct {
@@ -65,35 +75,55 @@ type CreateTag struct {
 	message    string
 	force      bool
 	createdTag *gitlab.Tag
+	// attempted 表示已经发送过创建请求，重试前需要检查标签是否已经创建
+	attempted bool
 }
 
 // Do 实现 Action 接口，执行创建标签的操作
-func (action *CreateTag) Do() *workflow.ActionError {
+func (action *CreateTag) Do(ctx context.Context) *workflow.ActionError {
 	if action.createdTag != nil {
 		return nil
 	}
+	if action.attempted {
+		tag, resp, err := action.client.Tags.GetTag(action.project, action.tag, gitlab.WithContext(ctx))
+		switch {
+		case err == nil && strings.TrimSpace(tag.Message) == strings.TrimSpace(action.message):
+			action.createdTag = tag
+			return nil
+		case err == nil:
+			return workflow.NewActionError(errors.Errorf("tag %s already exists", action.tag), false)
+		case !notFound(resp):
+			return apiError(err, resp, "get tag")
+		}
+	}
+	action.attempted = true
 	options := &gitlab.CreateTagOptions{
 		TagName: &action.tag,
 		Ref:     gitlab.String(action.branch()),
 		Message: &action.message,
 	}
-	tag, _, err := action.client.Tags.CreateTag(action.project, options)
+	tag, resp, err := action.client.Tags.CreateTag(action.project, options, gitlab.WithContext(ctx))
 	if err != nil {
-		return workflow.NewActionError(errors.Wrap(err, "create tag"), true)
+		return apiError(err, resp, "create tag")
 	}
 	action.createdTag = tag
 	return nil
 }
 
 // Undo 实现 Action 接口，撤销创建标签的操作
-func (action *CreateTag) Undo() error {
+func (action *CreateTag) Undo(ctx context.Context) error {
 	if action.createdTag == nil {
 		return nil
 	}
-	_, err := action.client.Tags.DeleteTag(action.project, action.tag)
+	_, err := action.client.Tags.DeleteTag(action.project, action.tag, gitlab.WithContext(ctx))
 	return err
 }
 
+// Describe 实现 Action 接口，描述创建标签的操作
+func (action *CreateTag) Describe() string {
+	return fmt.Sprintf("创建标签 %s，指向 %s\n标签消息: %s", action.tag, orPending(action.branch()), action.message)
+}
+
 // TagFunc 返回一个函数，用于获取创建的标签名称
 func (action *CreateTag) TagFunc() func() string {
 	return func() string {
@@ -104,6 +134,25 @@ func (action *CreateTag) TagFunc() func() string {
 	}
 }
 
+// ID 实现 workflow.Journaled 接口
+func (action *CreateTag) ID() string {
+	return "create-tag:" + action.tag
+}
+
+// State 实现 workflow.Journaled 接口，记录创建的标签
+func (action *CreateTag) State() map[string]string {
+	return map[string]string{"tag": action.createdTag.Name}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复已经创建的标签
+func (action *CreateTag) Restore(state map[string]string) error {
+	if state["tag"] == "" {
+		return errors.New("tag missing")
+	}
+	action.createdTag = &gitlab.Tag{Name: state["tag"]}
+	return nil
+}
+
 // NewCreateTag 创建一个新的创建标签操作
 func NewCreateTag(client *gitlab.Client, project string, branch func() string, tag string, message string, force bool) *CreateTag {
 	return &CreateTag{
@@ -158,23 +207,28 @@ type GetTag struct {
 }
 
 // Do 实现 Action 接口，执行获取标签的操作
-func (action *GetTag) Do() *workflow.ActionError {
+func (action *GetTag) Do(ctx context.Context) *workflow.ActionError {
 	if action.tagObj != nil {
 		return nil
 	}
-	tag, _, err := action.client.Tags.GetTag(action.project, action.tag)
+	tag, resp, err := action.client.Tags.GetTag(action.project, action.tag, gitlab.WithContext(ctx))
 	if err != nil {
-		return workflow.NewActionError(errors.Wrap(err, "get tag"), true)
+		return apiError(err, resp, "get tag")
 	}
 	action.tagObj = tag
 	return nil
 }
 
 // Undo 实现 Action 接口，撤销获取标签的操作（无需实现）
-func (action *GetTag) Undo() error {
+func (action *GetTag) Undo(ctx context.Context) error {
 	return nil
 }
 
+// Describe 实现 Action 接口，描述获取标签的操作
+func (action *GetTag) Describe() string {
+	return fmt.Sprintf("检查标签 %s 是否存在", action.tag)
+}
+
 // TagFunc 返回一个函数，用于获取标签对象
 func (action *GetTag) TagFunc() func() string {
 	return func() string {
@@ -185,6 +239,25 @@ func (action *GetTag) TagFunc() func() string {
 	}
 }
 
+// ID 实现 workflow.Journaled 接口
+func (action *GetTag) ID() string {
+	return "get-tag:" + action.tag
+}
+
+// State 实现 workflow.Journaled 接口，记录找到的标签
+func (action *GetTag) State() map[string]string {
+	return map[string]string{"tag": action.tagObj.Name}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复找到的标签
+func (action *GetTag) Restore(state map[string]string) error {
+	if state["tag"] == "" {
+		return errors.New("tag missing")
+	}
+	action.tagObj = &gitlab.Tag{Name: state["tag"]}
+	return nil
+}
+
 // NewGetTag 创建一个新的获取标签操作
 func NewGetTag(client *gitlab.Client, project string, tag string) *GetTag {
 	return &GetTag{
@@ -217,7 +290,7 @@ type AddLink struct {
 }
 
 // Do 实现 Action 接口，执行添加链接的操作
-func (action *AddLink) Do() *workflow.ActionError {
+func (action *AddLink) Do(ctx context.Context) *workflow.ActionError {
 	tag := action.tagFunc()
 	if tag == "" {
 		return workflow.NewActionError(errors.New("tag not set"), false)
@@ -226,23 +299,28 @@ func (action *AddLink) Do() *workflow.ActionError {
 	if link == "" {
 		return workflow.NewActionError(errors.New("link not set"), false)
 	}
-	_, _, err := gitlabutil.UpdateTagDescription(action.client, action.project, tag, action.linkDescription+"\n\n"+link)
+	_, resp, err := gitlabutil.UpdateTagDescription(action.client, action.project, tag, action.linkDescription+"\n\n"+link, gitlab.WithContext(ctx))
 	if err != nil {
-		return workflow.NewActionError(errors.Wrap(err, "add link"), true)
+		return apiError(err, resp, "add link")
 	}
 	return nil
 }
 
 // Undo 实现 Action 接口，撤销添加链接的操作
-func (action *AddLink) Undo() error {
+func (action *AddLink) Undo(ctx context.Context) error {
 	tag := action.tagFunc()
 	if tag == "" {
 		return nil
 	}
-	_, _, err := gitlabutil.UpdateTagDescription(action.client, action.project, tag, action.linkDescription)
+	_, _, err := gitlabutil.UpdateTagDescription(action.client, action.project, tag, action.linkDescription, gitlab.WithContext(ctx))
 	return err
 }
 
+// Describe 实现 Action 接口，描述添加链接的操作
+func (action *AddLink) Describe() string {
+	return fmt.Sprintf("在标签 %s 的发布说明中添加链接 %s", orPending(action.tagFunc()), orPending(action.mdLinkFunc()))
+}
+
 // NewAddLink 创建一个新的添加链接操作
 func NewAddLink(params *AddLinkParams) *AddLink {
 	return &AddLink{
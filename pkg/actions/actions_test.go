@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -103,7 +104,7 @@ func TestTagAction(t *testing.T) {
 	client := newClient(t)
 	tag := "v1.0.0"
 	action := NewCreateTag(client, projectPath, func() string { return "master" }, tag, "test tag", true)
-	err := action.Do()
+	err := action.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -117,22 +118,22 @@ func TestGetTagAction(t *testing.T) {
 	client := newClient(t)
 	tag := "v1.0.0"
 	action := NewCreateTag(client, projectPath, func() string { return "master" }, tag, "test tag", true)
-	err := action.Do()
+	err := action.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
 	tagExits(t, project, tag)
 
 	getAction := NewGetTag(client, projectPath, tag)
-	err = getAction.Do()
+	err = getAction.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
-	if getAction.tag == nil {
+	if getAction.tagObj == nil {
 		t.Fatal("tag not found")
 	}
-	if getAction.tag.Name != tag {
-		t.Fatalf("tag name %s != %s", getAction.tag.Name, tag)
+	if getAction.tagObj.Name != tag {
+		t.Fatalf("tag name %s != %s", getAction.tagObj.Name, tag)
 	}
 }
 
@@ -143,7 +144,7 @@ func TestAddLink(t *testing.T) {
 	client := newClient(t)
 	tag := "v1.0.0"
 	action := NewCreateTag(client, projectPath, func() string { return "master" }, tag, "test tag", true)
-	err := action.Do()
+	err := action.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -157,7 +158,7 @@ func TestAddLink(t *testing.T) {
 		MDLinkFunc:      func() string { return fmt.Sprintf("[example](%s)", link) },
 		TagFunc:         action.TagFunc(),
 	})
-	err = linkAction.Do()
+	err = linkAction.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -182,7 +183,7 @@ func TestUpload(t *testing.T) {
 		t.Fatal(err)
 	}
 	action := NewUpload(client, projectPath, projectURL, file)
-	err = action.Do()
+	err = action.Do(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
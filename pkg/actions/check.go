@@ -1,6 +1,7 @@
 package actions
 
 import (
+	"context"
 	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	gitlab "github.com/xanzy/go-gitlab"
 )
@@ -13,23 +14,15 @@ type Check struct {
 }
 
 // Do implements Action for Check
-func (action *Check) Do() *workflow.ActionError {
+func (action *Check) Do(ctx context.Context) *workflow.ActionError {
 
-	_, resp, err := action.client.Users.CurrentUser()
+	_, resp, err := action.client.Users.CurrentUser(gitlab.WithContext(ctx))
 	if err != nil {
-		retry := false
-		if resp != nil && resp.StatusCode == 502 {
-			retry = true
-		}
-		return workflow.NewActionError(err, retry)
+		return apiError(err, resp, "get current user")
 	}
-	v, resp, err := action.client.Version.GetVersion()
+	v, resp, err := action.client.Version.GetVersion(gitlab.WithContext(ctx))
 	if err != nil {
-		retry := false
-		if resp != nil && resp.StatusCode == 502 {
-			retry = true
-		}
-		return workflow.NewActionError(err, retry)
+		return apiError(err, resp, "get version")
 	}
 	action.Version = v.Version
 	action.Revision = v.Revision
@@ -37,10 +30,15 @@ func (action *Check) Do() *workflow.ActionError {
 }
 
 // Undo implements Action for Check
-func (action *Check) Undo() error {
+func (action *Check) Undo(ctx context.Context) error {
 	return nil
 }
 
+// Describe implements Action for Check
+func (action *Check) Describe() string {
+	return "检查 GitLab API 连接"
+}
+
 // NewCheck creates a Check action
 func NewCheck(client *gitlab.Client) *Check {
 	return &Check{
@@ -1,8 +1,12 @@
 package actions
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
+	"strings"
 
 	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	"github.com/pkg/errors"
@@ -117,28 +121,74 @@ type Commit struct {
 	project  string
 	branch   string
 	message  string
+	files    []string
 	commitID string
+	// attempted 表示已经发送过创建请求，重试前需要检查分支上是否已经有这个提交
+	attempted bool
 }
 
 // Do 实现 Action 接口，执行创建提交的操作
-func (action *Commit) Do() *workflow.ActionError {
+func (action *Commit) Do(ctx context.Context) *workflow.ActionError {
 	if action.commitID != "" {
 		return nil
 	}
+	if action.attempted {
+		commit, resp, err := action.client.Commits.GetCommit(action.project, action.branch, gitlab.WithContext(ctx))
+		if err != nil {
+			return apiError(err, resp, "get commit")
+		}
+		if strings.TrimSpace(commit.Message) == strings.TrimSpace(action.message) {
+			action.commitID = commit.ID
+			return nil
+		}
+	}
+	fileActions := make([]*gitlab.CommitActionOptions, 0, len(action.files))
+	for _, file := range action.files {
+		fileAction, err := action.fileAction(ctx, file)
+		if err != nil {
+			return err
+		}
+		fileActions = append(fileActions, fileAction)
+	}
 	options := &gitlab.CreateCommitOptions{
 		Branch:        &action.branch,
 		CommitMessage: &action.message,
+		Actions:       fileActions,
 	}
-	commit, _, err := action.client.Commits.CreateCommit(action.project, options)
+	action.attempted = true
+	commit, resp, err := action.client.Commits.CreateCommit(action.project, options, gitlab.WithContext(ctx))
 	if err != nil {
-		return workflow.NewActionError(errors.Wrap(err, "create commit"), true)
+		return apiError(err, resp, "create commit")
 	}
 	action.commitID = commit.ID
 	return nil
 }
 
+// fileAction 读取本地文件，根据文件是否已存在于分支中选择创建或更新
+func (action *Commit) fileAction(ctx context.Context, file string) (*gitlab.CommitActionOptions, *workflow.ActionError) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, workflow.NewActionError(errors.Wrap(err, "read file"), false)
+	}
+	fileAction := gitlab.FileUpdate
+	_, resp, err := action.client.RepositoryFiles.GetFileMetaData(action.project, file, &gitlab.GetFileMetaDataOptions{
+		Ref: gitlab.String(action.branch),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return nil, apiError(err, resp, "get file "+file)
+		}
+		fileAction = gitlab.FileCreate
+	}
+	return &gitlab.CommitActionOptions{
+		Action:   gitlab.FileAction(fileAction),
+		FilePath: gitlab.String(file),
+		Content:  gitlab.String(string(content)),
+	}, nil
+}
+
 // Undo 实现 Action 接口，撤销创建提交的操作
-func (action *Commit) Undo() error {
+func (action *Commit) Undo(ctx context.Context) error {
 	if action.commitID == "" {
 		return nil
 	}
@@ -146,6 +196,16 @@ func (action *Commit) Undo() error {
 	return nil
 }
 
+// Describe 实现 Action 接口，描述创建提交的操作
+func (action *Commit) Describe() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "在分支 %s 上创建提交\n提交消息: %s", action.branch, action.message)
+	for _, file := range action.files {
+		fmt.Fprintf(&b, "\n- %s", file)
+	}
+	return b.String()
+}
+
 // BranchFunc 返回一个函数，用于获取分支名称
 func (action *Commit) BranchFunc() func() string {
 	return func() string {
@@ -153,12 +213,43 @@ func (action *Commit) BranchFunc() func() string {
 	}
 }
 
-// NewCommit 创建一个新的创建提交操作
-func NewCommit(client *gitlab.Client, project string, branch string, message string) *Commit {
+// CommitIDFunc 返回一个函数，用于获取新提交的哈希
+func (action *Commit) CommitIDFunc() func() string {
+	return func() string {
+		return action.commitID
+	}
+}
+
+// ID 实现 workflow.Journaled 接口
+func (action *Commit) ID() string {
+	return "commit:" + action.branch
+}
+
+// State 实现 workflow.Journaled 接口，记录新提交的哈希和提交消息
+func (action *Commit) State() map[string]string {
+	return map[string]string{"commit_id": action.commitID, "message": action.message}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复已经创建的提交。
+// 同一分支上的每次发布都使用相同的 ID，提交消息不同时日志属于另一次发布，不能恢复
+func (action *Commit) Restore(state map[string]string) error {
+	if state["commit_id"] == "" {
+		return errors.New("commit id missing")
+	}
+	if state["message"] != action.message {
+		return errors.Errorf("commit %s has message %q, not %q", state["commit_id"], state["message"], action.message)
+	}
+	action.commitID = state["commit_id"]
+	return nil
+}
+
+// NewCommit 创建一个新的创建提交操作，files 是要提交的本地文件
+func NewCommit(client *gitlab.Client, project string, branch string, message string, files []string) *Commit {
 	return &Commit{
 		client:  client,
 		project: project,
 		branch:  branch,
 		message: message,
+		files:   files,
 	}
 }
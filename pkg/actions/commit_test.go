@@ -0,0 +1,56 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+)
+
+func TestCommitRestore(t *testing.T) {
+	action := NewCommit(nil, "group/project", "main", "chore: 版本更新为 v1.1.0 [skip ci]", []string{"VERSION"})
+	if err := action.Restore(map[string]string{"commit_id": "abc", "message": "chore: 版本更新为 v1.0.0 [skip ci]"}); err == nil {
+		t.Error("Expected error for a commit of another release")
+	}
+	if err := action.Restore(map[string]string{"commit_id": "abc"}); err == nil {
+		t.Error("Expected error for a journal without commit message")
+	}
+	if action.CommitIDFunc()() != "" {
+		t.Error("Expected commit not to be restored")
+	}
+
+	previous := NewCommit(nil, "group/project", "main", "chore: 版本更新为 v1.1.0 [skip ci]", []string{"VERSION"})
+	previous.commitID = "abc"
+	if err := action.Restore(previous.State()); err != nil {
+		t.Fatal(err)
+	}
+	if action.CommitIDFunc()() != "abc" {
+		t.Errorf("Expected commit abc, got %s", action.CommitIDFunc()())
+	}
+}
+
+func TestCommitStaleJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	data, err := json.Marshal(workflow.Journal{
+		Version: workflow.JournalVersion,
+		Completed: []workflow.JournalEntry{{
+			ID:    "commit:main",
+			State: map[string]string{"commit_id": "abc", "message": "chore: 版本更新为 v1.0.0 [skip ci]"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端为 nil，如果没有拒绝过期的日志而执行了操作，测试会 panic
+	action := NewCommit(nil, "group/project", "main", "chore: 版本更新为 v1.1.0 [skip ci]", []string{"VERSION"})
+	if err := workflow.ApplyWithJournal(context.Background(), []workflow.Action{action}, path, workflow.RetryPolicy{}); err == nil {
+		t.Fatal("Expected error for a journal of another release")
+	}
+}
@@ -0,0 +1,58 @@
+package actions
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// apiError 把 GitLab API 返回的错误包装为 workflow.ActionError。
+// 网络超时和 5xx 错误可以重试，429 和 503 还会遵守响应中的 Retry-After。
+func apiError(err error, resp *gitlab.Response, message string) *workflow.ActionError {
+	wrapped := errors.Wrap(err, message)
+	if resp == nil || resp.Response == nil {
+		var netErr net.Error
+		timeout := errors.As(err, &netErr) && netErr.Timeout()
+		return workflow.NewActionError(wrapped, timeout)
+	}
+	switch code := resp.StatusCode; {
+	case code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable:
+		return workflow.NewRetryAfterError(wrapped, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	case code >= 500:
+		return workflow.NewActionError(wrapped, true)
+	default:
+		return workflow.NewActionError(wrapped, false)
+	}
+}
+
+// notFound 判断 GitLab 是否返回了 404。
+// 创建操作重试前用它检查上一次请求是否已经在服务器上生效：超时或 5xx 错误时请求可能已经被处理，
+// 重新创建只会得到 "already exists"
+func notFound(resp *gitlab.Response) bool {
+	return resp != nil && resp.Response != nil && resp.StatusCode == http.StatusNotFound
+}
+
+// parseRetryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式。
+// 无法解析或者已经过期时返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
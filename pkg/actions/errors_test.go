@@ -0,0 +1,184 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/gitlabutil"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func response(code int, retryAfter string) *gitlab.Response {
+	header := http.Header{}
+	if retryAfter != "" {
+		header.Set("Retry-After", retryAfter)
+	}
+	return &gitlab.Response{Response: &http.Response{StatusCode: code, Header: header}}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		resp       *gitlab.Response
+		retry      bool
+		retryAfter time.Duration
+	}{
+		{"timeout", timeoutError{}, nil, true, 0},
+		{"connection refused", errors.New("connection refused"), nil, false, 0},
+		{"bad gateway", errors.New("502"), response(502, ""), true, 0},
+		{"internal error", errors.New("500"), response(500, ""), true, 0},
+		{"rate limited", errors.New("429"), response(429, "12"), true, 12 * time.Second},
+		{"unavailable", errors.New("503"), response(503, ""), true, 0},
+		{"bad request", errors.New("400"), response(400, ""), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aErr := apiError(tt.err, tt.resp, "test")
+			if aErr.Retryable() != tt.retry {
+				t.Errorf("Expected retryable %v, got %v", tt.retry, aErr.Retryable())
+			}
+			if aErr.RetryAfter() != tt.retryAfter {
+				t.Errorf("Expected retry after %s, got %s", tt.retryAfter, aErr.RetryAfter())
+			}
+		})
+	}
+}
+
+// fakeCreate 模拟 GitLab 中的一个资源：第一次创建请求返回 502，created 为 true 时资源已经在服务器上创建，
+// 之后的创建请求成功；查询请求在资源创建后返回资源，否则返回 404 或空列表
+type fakeCreate struct {
+	create  string
+	lookup  string
+	body    string
+	list    bool
+	created bool
+	mu      sync.Mutex
+	posts   int
+}
+
+func (f *fakeCreate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodHead && strings.Contains(path, "/repository/files/"):
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, f.create):
+		f.posts++
+		if f.posts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		f.created = true
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(f.body))
+	case r.Method == http.MethodGet && f.lookup != "" && strings.HasSuffix(path, f.lookup):
+		switch {
+		case f.created && f.list:
+			w.Write([]byte("[" + f.body + "]"))
+		case f.created:
+			w.Write([]byte(f.body))
+		case f.list:
+			w.Write([]byte("[]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestCreateRetried 检查创建操作在 502 之后重试：上一次请求已经生效时采用已经创建的资源，不重复创建
+func TestCreateRetried(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.tar.gz")
+	if err := os.WriteFile(file, []byte("app"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	projectURL, _ := url.Parse("https://gitlab.example.com/group/project")
+	tag := func(client *gitlab.Client) workflow.Action {
+		return NewCreateTag(client, "group/project", NewFuncOfString("main"), "v1.0.0", "Release v1.0.0", false)
+	}
+	tests := []struct {
+		name   string
+		fake   *fakeCreate
+		action func(client *gitlab.Client) workflow.Action
+		posts  int
+	}{
+		{"create tag", &fakeCreate{create: "/repository/tags", lookup: "/repository/tags/v1.0.0", body: `{"name":"v1.0.0","message":"Release v1.0.0"}`, created: true}, tag, 1},
+		{"create tag not created", &fakeCreate{create: "/repository/tags", lookup: "/repository/tags/v1.0.0", body: `{"name":"v1.0.0","message":"Release v1.0.0"}`}, tag, 2},
+		{"create release", &fakeCreate{create: "/releases", lookup: "/releases/v1.0.0", body: `{"name":"1.0.0","tag_name":"v1.0.0"}`, created: true}, func(client *gitlab.Client) workflow.Action {
+			return NewCreateRelease(client, "group/project", NewFuncOfString("v1.0.0"), "1.0.0", "notes")
+		}, 1},
+		{"create release link", &fakeCreate{create: "/assets/links", lookup: "/assets/links", body: `{"id":1,"name":"app","url":"https://example.com/app"}`, list: true, created: true}, func(client *gitlab.Client) workflow.Action {
+			return NewCreateReleaseLink(client, "group/project", NewFuncOfString("v1.0.0"), "app", NewFuncOfString("https://example.com/app"))
+		}, 1},
+		{"create commit", &fakeCreate{create: "/repository/commits", lookup: "/repository/commits/main", body: `{"id":"abc","message":"chore: release\n"}`, created: true}, func(client *gitlab.Client) workflow.Action {
+			return NewCommit(client, "group/project", "main", "chore: release", []string{file})
+		}, 1},
+		{"create pipeline", &fakeCreate{create: "/pipeline", lookup: "/pipelines", body: `{"id":5}`, list: true, created: true}, func(client *gitlab.Client) workflow.Action {
+			return NewCreatePipeline(client, "group/project", NewFuncOfString("v1.0.0"))
+		}, 1},
+		{"upload file", &fakeCreate{create: "/uploads", body: `{"alt":"app.tar.gz","url":"/uploads/1/app.tar.gz"}`}, func(client *gitlab.Client) workflow.Action {
+			return NewUpload(client, "group/project", projectURL, file)
+		}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.fake)
+			defer server.Close()
+			client, err := gitlabutil.NewClient("token", server.URL, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			policy := workflow.RetryPolicy{
+				MaxRetries: 3,
+				Multiplier: 1,
+				Sleep:      func(ctx context.Context, d time.Duration) error { return nil },
+			}
+			if err := workflow.ApplyWithPolicy(context.Background(), []workflow.Action{tt.action(client)}, policy); err != nil {
+				t.Fatal(err)
+			}
+			if tt.fake.posts != tt.posts {
+				t.Errorf("Expected %d create requests, got %d", tt.posts, tt.fake.posts)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-1", 0},
+		{"Mon, 01 Jan 2024 12:01:00 GMT", time.Minute},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.expected {
+			t.Errorf("parseRetryAfter(%q) = %s, expected %s", tt.value, got, tt.expected)
+		}
+	}
+}
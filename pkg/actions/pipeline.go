@@ -1,7 +1,9 @@
 package actions
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	"github.com/pkg/errors"
@@ -14,26 +16,43 @@ type CreatePipeline struct {
 	project    string
 	branch     func() string
 	pipelineID int
+	// attempted 表示已经发送过创建请求，重试前需要检查是否已经有通过 API 创建的流水线
+	attempted bool
 }
 
 // Do 实现 Action 接口，执行创建流水线的操作
-func (action *CreatePipeline) Do() *workflow.ActionError {
+func (action *CreatePipeline) Do(ctx context.Context) *workflow.ActionError {
 	if action.pipelineID != 0 {
 		return nil
 	}
+	ref := action.branch()
+	if action.attempted {
+		pipelines, resp, err := action.client.Pipelines.ListProjectPipelines(action.project, &gitlab.ListProjectPipelinesOptions{
+			Ref:    gitlab.String(ref),
+			Source: gitlab.String("api"),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return apiError(err, resp, "list pipelines")
+		}
+		if len(pipelines) > 0 {
+			action.pipelineID = pipelines[0].ID
+			return nil
+		}
+	}
+	action.attempted = true
 	options := &gitlab.CreatePipelineOptions{
-		Ref: gitlab.String(action.branch()),
+		Ref: gitlab.String(ref),
 	}
-	pipeline, _, err := action.client.Pipelines.CreatePipeline(action.project, options)
+	pipeline, resp, err := action.client.Pipelines.CreatePipeline(action.project, options, gitlab.WithContext(ctx))
 	if err != nil {
-		return workflow.NewActionError(errors.Wrap(err, "create pipeline"), true)
+		return apiError(err, resp, "create pipeline")
 	}
 	action.pipelineID = pipeline.ID
 	return nil
 }
 
 // Undo 实现 Action 接口，撤销创建流水线的操作
-func (action *CreatePipeline) Undo() error {
+func (action *CreatePipeline) Undo(ctx context.Context) error {
 	if action.pipelineID == 0 {
 		return nil
 	}
@@ -41,6 +60,31 @@ func (action *CreatePipeline) Undo() error {
 	return nil
 }
 
+// Describe 实现 Action 接口，描述创建流水线的操作
+func (action *CreatePipeline) Describe() string {
+	return fmt.Sprintf("为 %s 创建流水线", orPending(action.branch()))
+}
+
+// ID 实现 workflow.Journaled 接口
+func (action *CreatePipeline) ID() string {
+	return "create-pipeline"
+}
+
+// State 实现 workflow.Journaled 接口，记录流水线 ID
+func (action *CreatePipeline) State() map[string]string {
+	return map[string]string{"pipeline_id": strconv.Itoa(action.pipelineID)}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复已经创建的流水线
+func (action *CreatePipeline) Restore(state map[string]string) error {
+	id, err := strconv.Atoi(state["pipeline_id"])
+	if err != nil {
+		return errors.Wrap(err, "parse pipeline id")
+	}
+	action.pipelineID = id
+	return nil
+}
+
 // NewCreatePipeline 创建一个新的创建流水线操作
 func NewCreatePipeline(client *gitlab.Client, project string, branch func() string) *CreatePipeline {
 	return &CreatePipeline{
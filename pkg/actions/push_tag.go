@@ -0,0 +1,94 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/service"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	"github.com/pkg/errors"
+)
+
+// PushTag 表示在本地仓库创建标签并推送到远程仓库的操作，
+// 与 CreateTag 通过 GitLab API 创建的标签相同
+type PushTag struct {
+	git     *service.GitService
+	opts    service.PushOptions
+	ref     func() string
+	tag     string
+	message string
+	pushed  bool
+}
+
+// Do 实现 Action 接口，执行创建并推送标签的操作
+func (action *PushTag) Do(ctx context.Context) *workflow.ActionError {
+	if action.pushed {
+		return nil
+	}
+	if err := action.git.PushTag(ctx, action.tag, action.message, action.ref(), action.opts); err != nil {
+		return workflow.NewActionError(errors.Wrap(err, "push tag"), false)
+	}
+	action.pushed = true
+	return nil
+}
+
+// Undo 实现 Action 接口，删除远程和本地的标签
+func (action *PushTag) Undo(ctx context.Context) error {
+	if !action.pushed {
+		return nil
+	}
+	if err := action.git.DeleteTag(ctx, action.tag, action.opts); err != nil {
+		return errors.Wrap(err, "delete pushed tag")
+	}
+	action.pushed = false
+	return nil
+}
+
+// Describe 实现 Action 接口，描述创建并推送标签的操作
+func (action *PushTag) Describe() string {
+	remote := action.opts.RemoteURL
+	if remote == "" {
+		remote = "origin"
+	}
+	return fmt.Sprintf("在本地仓库创建标签 %s，指向 %s，并推送到 %s\n标签消息: %s", action.tag, orPending(action.ref()), remote, action.message)
+}
+
+// ID 实现 workflow.Journaled 接口
+func (action *PushTag) ID() string {
+	return "push-tag:" + action.tag
+}
+
+// State 实现 workflow.Journaled 接口，记录推送的标签
+func (action *PushTag) State() map[string]string {
+	return map[string]string{"tag": action.tag}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复已经推送的标签
+func (action *PushTag) Restore(state map[string]string) error {
+	if state["tag"] != action.tag {
+		return errors.Errorf("unexpected tag %s", state["tag"])
+	}
+	action.pushed = true
+	return nil
+}
+
+// TagFunc 返回一个函数，用于获取推送的标签名称
+func (action *PushTag) TagFunc() func() string {
+	return func() string {
+		if !action.pushed {
+			return ""
+		}
+		return action.tag
+	}
+}
+
+// NewPushTag 创建一个新的推送标签操作，ref 必须是本地仓库中存在的提交
+func NewPushTag(git *service.GitService, opts service.PushOptions, ref func() string, tag string, message string) *PushTag {
+	return &PushTag{
+		git:     git,
+		opts:    opts,
+		ref:     ref,
+		tag:     tag,
+		message: message,
+	}
+}
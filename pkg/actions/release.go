@@ -0,0 +1,220 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	"github.com/pkg/errors"
+	gitlab "github.com/xanzy/go-gitlab"
+)
+
+// CreateRelease 表示创建 GitLab 发布的操作
+type CreateRelease struct {
+	client      *gitlab.Client
+	project     string
+	tagFunc     func() string
+	name        string
+	description string
+	release     *gitlab.Release
+	// attempted 表示已经发送过创建请求，重试前需要检查发布是否已经创建
+	attempted bool
+}
+
+// Do 实现 Action 接口，执行创建发布的操作
+func (action *CreateRelease) Do(ctx context.Context) *workflow.ActionError {
+	if action.release != nil {
+		return nil
+	}
+	tag := action.tagFunc()
+	if tag == "" {
+		return workflow.NewActionError(errors.New("tag not set"), false)
+	}
+	if action.attempted {
+		release, resp, err := action.client.Releases.GetRelease(action.project, tag, gitlab.WithContext(ctx))
+		switch {
+		case err == nil && release.Name == action.name:
+			action.release = release
+			return nil
+		case err == nil:
+			return workflow.NewActionError(errors.Errorf("release for tag %s already exists", tag), false)
+		case !notFound(resp):
+			return apiError(err, resp, "get release")
+		}
+	}
+	action.attempted = true
+	release, resp, err := action.client.Releases.CreateRelease(action.project, &gitlab.CreateReleaseOptions{
+		Name:        gitlab.String(action.name),
+		TagName:     gitlab.String(tag),
+		Description: gitlab.String(action.description),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return apiError(err, resp, "create release")
+	}
+	action.release = release
+	return nil
+}
+
+// Undo 实现 Action 接口，撤销创建发布的操作
+func (action *CreateRelease) Undo(ctx context.Context) error {
+	if action.release == nil {
+		return nil
+	}
+	_, _, err := action.client.Releases.DeleteRelease(action.project, action.release.TagName, gitlab.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "delete release")
+	}
+	action.release = nil
+	return nil
+}
+
+// Describe 实现 Action 接口，描述创建发布的操作
+func (action *CreateRelease) Describe() string {
+	return fmt.Sprintf("为标签 %s 创建发布 %s\n发布说明:\n%s", orPending(action.tagFunc()), action.name, action.description)
+}
+
+// TagFunc 返回一个函数，用于获取发布所属的标签名称
+func (action *CreateRelease) TagFunc() func() string {
+	return func() string {
+		if action.release == nil {
+			return ""
+		}
+		return action.release.TagName
+	}
+}
+
+// ID 实现 workflow.Journaled 接口
+func (action *CreateRelease) ID() string {
+	return "create-release:" + action.name
+}
+
+// State 实现 workflow.Journaled 接口，记录发布所属的标签
+func (action *CreateRelease) State() map[string]string {
+	return map[string]string{"tag": action.release.TagName}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复已经创建的发布
+func (action *CreateRelease) Restore(state map[string]string) error {
+	if state["tag"] == "" {
+		return errors.New("tag missing")
+	}
+	action.release = &gitlab.Release{TagName: state["tag"]}
+	return nil
+}
+
+// NewCreateRelease 创建一个新的创建发布操作
+func NewCreateRelease(client *gitlab.Client, project string, tagFunc func() string, name string, description string) *CreateRelease {
+	return &CreateRelease{
+		client:      client,
+		project:     project,
+		tagFunc:     tagFunc,
+		name:        name,
+		description: description,
+	}
+}
+
+// CreateReleaseLink 表示向 GitLab 发布添加资源链接的操作
+type CreateReleaseLink struct {
+	client  *gitlab.Client
+	project string
+	tagFunc func() string
+	name    string
+	urlFunc func() string
+	link    *gitlab.ReleaseLink
+	tag     string
+	// attempted 表示已经发送过创建请求，重试前需要检查链接是否已经添加
+	attempted bool
+}
+
+// Do 实现 Action 接口，执行添加发布链接的操作
+func (action *CreateReleaseLink) Do(ctx context.Context) *workflow.ActionError {
+	if action.link != nil {
+		return nil
+	}
+	tag := action.tagFunc()
+	if tag == "" {
+		return workflow.NewActionError(errors.New("tag not set"), false)
+	}
+	linkURL := action.urlFunc()
+	if linkURL == "" {
+		return workflow.NewActionError(errors.New("link url not set"), false)
+	}
+	if action.attempted {
+		links, resp, err := action.client.ReleaseLinks.ListReleaseLinks(action.project, tag, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return apiError(err, resp, "list release links")
+		}
+		for _, link := range links {
+			if link.Name == action.name && link.URL == linkURL {
+				action.link = link
+				action.tag = tag
+				return nil
+			}
+		}
+	}
+	action.attempted = true
+	link, resp, err := action.client.ReleaseLinks.CreateReleaseLink(action.project, tag, &gitlab.CreateReleaseLinkOptions{
+		Name: gitlab.String(action.name),
+		URL:  gitlab.String(linkURL),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return apiError(err, resp, "create release link")
+	}
+	action.link = link
+	action.tag = tag
+	return nil
+}
+
+// Undo 实现 Action 接口，撤销添加发布链接的操作
+func (action *CreateReleaseLink) Undo(ctx context.Context) error {
+	if action.link == nil {
+		return nil
+	}
+	_, _, err := action.client.ReleaseLinks.DeleteReleaseLink(action.project, action.tag, action.link.ID, gitlab.WithContext(ctx))
+	if err != nil {
+		return errors.Wrapf(err, "delete release link %s", action.name)
+	}
+	action.link = nil
+	return nil
+}
+
+// Describe 实现 Action 接口，描述添加发布链接的操作
+func (action *CreateReleaseLink) Describe() string {
+	return fmt.Sprintf("在标签 %s 的发布中添加链接 %s: %s", orPending(action.tagFunc()), action.name, orPending(action.urlFunc()))
+}
+
+// ID 实现 workflow.Journaled 接口
+func (action *CreateReleaseLink) ID() string {
+	return "create-release-link:" + action.name
+}
+
+// State 实现 workflow.Journaled 接口，记录链接所在的标签和链接 ID
+func (action *CreateReleaseLink) State() map[string]string {
+	return map[string]string{"tag": action.tag, "link_id": strconv.Itoa(action.link.ID)}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复已经添加的链接
+func (action *CreateReleaseLink) Restore(state map[string]string) error {
+	if state["tag"] == "" {
+		return errors.New("tag missing")
+	}
+	id, err := strconv.Atoi(state["link_id"])
+	if err != nil {
+		return errors.Wrap(err, "parse link id")
+	}
+	action.tag = state["tag"]
+	action.link = &gitlab.ReleaseLink{ID: id, Name: action.name}
+	return nil
+}
+
+// NewCreateReleaseLink 创建一个新的添加发布链接操作
+func NewCreateReleaseLink(client *gitlab.Client, project string, tagFunc func() string, name string, urlFunc func() string) *CreateReleaseLink {
+	return &CreateReleaseLink{
+		client:  client,
+		project: project,
+		tagFunc: tagFunc,
+		name:    name,
+		urlFunc: urlFunc,
+	}
+}
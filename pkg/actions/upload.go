@@ -1,11 +1,13 @@
 package actions
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
 	"github.com/pkg/errors"
@@ -27,6 +29,9 @@ type UploadFileResponse struct {
 	Markdown string `json:"markdown"`
 }
 
+// uploadMaxDelay 是上传文件重试之间的最长等待时间
+const uploadMaxDelay = 5 * time.Minute
+
 // Upload 表示 GitLab 文件上传操作
 type Upload struct {
 	client             *gitlab.Client
@@ -38,7 +43,7 @@ type Upload struct {
 }
 
 // Do 实现 Action 接口，执行文件上传操作
-func (action *Upload) Do() *workflow.ActionError {
+func (action *Upload) Do(ctx context.Context) *workflow.ActionError {
 	if action.projectFile != nil {
 		return nil
 	}
@@ -53,13 +58,11 @@ func (action *Upload) Do() *workflow.ActionError {
 		action.project,
 		file,
 		filepath.Base(action.file),
+		gitlab.WithContext(ctx),
 	)
 	if err != nil {
-		retry := false
-		if resp.StatusCode == 502 {
-			retry = true
-		}
-		return workflow.NewActionError(errors.Wrap(err, "upload file"), retry)
+		// 重复上传只会在项目中多出一个没有被引用的文件，可以直接重试
+		return apiError(err, resp, "upload file")
 	}
 	action.projectFile = projectFile
 
@@ -71,7 +74,7 @@ func (action *Upload) Do() *workflow.ActionError {
 }
 
 // Undo 实现 Action 接口，撤销文件上传操作
-func (action *Upload) Undo() error {
+func (action *Upload) Undo(ctx context.Context) error {
 	if action.projectFile == nil {
 		return nil
 	}
@@ -79,6 +82,20 @@ func (action *Upload) Undo() error {
 	return nil
 }
 
+// Describe 实现 Action 接口，描述文件上传操作
+func (action *Upload) Describe() string {
+	return fmt.Sprintf("上传文件 %s 到项目 %s", action.file, action.project)
+}
+
+// RetryPolicy 实现 workflow.PolicyOverride 接口。
+// 上传大量文件时容易触发限流，允许按照 Retry-After 等待更长时间
+func (action *Upload) RetryPolicy(p workflow.RetryPolicy) workflow.RetryPolicy {
+	if p.MaxDelay < uploadMaxDelay {
+		p.MaxDelay = uploadMaxDelay
+	}
+	return p
+}
+
 // MDLinkFunc 返回一个函数，用于获取 Markdown 格式的文件链接
 func (action *Upload) MDLinkFunc() func() string {
 	return func() string {
@@ -99,6 +116,30 @@ func (action *Upload) LinkURLFunc() func() string {
 	}
 }
 
+// ID 实现 workflow.Journaled 接口
+func (action *Upload) ID() string {
+	return "upload:" + action.file
+}
+
+// State 实现 workflow.Journaled 接口，记录上传后的相对 URL
+func (action *Upload) State() map[string]string {
+	return map[string]string{"url": action.projectFile.URL}
+}
+
+// Restore 实现 workflow.Journaled 接口，恢复已经上传的文件
+func (action *Upload) Restore(state map[string]string) error {
+	if state["url"] == "" {
+		return errors.New("upload url missing")
+	}
+	fullURL, err := url.Parse(action.projectURL.String() + state["url"])
+	if err != nil {
+		return errors.Wrap(err, "parse url")
+	}
+	action.projectFile = &gitlab.ProjectFile{URL: state["url"]}
+	action.fullprojectFileURL = fullURL
+	return nil
+}
+
 // NewUpload 创建一个新的文件上传操作
 func NewUpload(client *gitlab.Client, project string, projectURL *url.URL, file string) *Upload {
 	return &Upload{
@@ -0,0 +1,934 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/fanny7d/semrel-gitlab/pkg/render"
+	"github.com/fanny7d/semrel-gitlab/pkg/workflow"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName 是默认的配置文件名
+const FileName = ".semrelrc.yml"
+
+// EnvPrefix 是覆盖命令行选项的环境变量前缀
+const EnvPrefix = "GSG_"
+
+// OutputFormats 是只读命令支持的输出格式，text 为默认的文本输出
+var OutputFormats = []string{"text", "json", "yaml", "dotenv"}
+
+// File 表示 .semrelrc.yml 配置文件的内容
+type File struct {
+	GitLab  GitLabSection  `yaml:"gitlab"`
+	Version VersionSection `yaml:"version"`
+	Commit  CommitSection  `yaml:"commit"`
+	Release ReleaseSection `yaml:"release"`
+	CI      CISection      `yaml:"ci"`
+	// Components 是 monorepo 中独立发布的组件
+	Components []ComponentSection `yaml:"components"`
+	// Channels 是按分支选择的发布渠道，没有设置时使用 DefaultChannels
+	Channels []ChannelSection `yaml:"channels"`
+}
+
+// GitLabSection 是 GitLab 相关配置
+type GitLabSection struct {
+	APIURL        *string `yaml:"api_url"`
+	SkipSSLVerify *bool   `yaml:"skip_ssl_verify"`
+}
+
+// VersionSection 是版本控制配置
+type VersionSection struct {
+	TagPrefix          *string  `yaml:"tag_prefix"`
+	InitialDevelopment *bool    `yaml:"initial_development"`
+	PreTemplates       []string `yaml:"pre_templates"`
+	BuildTemplates     []string `yaml:"build_templates"`
+	// Scheme 是版本号方案: semver 或 calver
+	Scheme *string `yaml:"scheme"`
+	// CalVerFormat 是日历版本的格式，例如 YYYY.MM.MICRO
+	CalVerFormat *string `yaml:"calver_format"`
+	// Files 是 commit-and-tag 在发布提交中更新版本号的文件
+	Files []VersionFileSection `yaml:"files"`
+}
+
+// VersionFileSection 是一个版本文件的配置
+type VersionFileSection struct {
+	Path string `yaml:"path"`
+	// Type 是文件类型，例如 npm、helm 或 regex，为空时根据文件名推断
+	Type string `yaml:"type"`
+	// Pattern 是 regex 类型匹配版本号的正则表达式，必须包含命名分组 version
+	Pattern string `yaml:"pattern"`
+	// Marker 是 marker 类型的标记，更新包含标记的行中的版本号
+	Marker string `yaml:"marker"`
+}
+
+// CommitSection 是提交分析配置
+type CommitSection struct {
+	PatchTypes  []string `yaml:"patch_types"`
+	MinorTypes  []string `yaml:"minor_types"`
+	IgnoreTypes []string `yaml:"ignore_types"`
+	// MergeStrategy 是分析合并提交的方式: title 或 branch
+	MergeStrategy *string `yaml:"merge_strategy"`
+	// Convention 是提交消息遵循的约定，例如 conventional、gitmoji 或 regex
+	Convention *string `yaml:"convention"`
+	// Pattern 是 regex 约定解析标题行的正则表达式
+	Pattern string `yaml:"pattern"`
+	// TypeMap 把解析出的类型映射为版本计算使用的类型，以 ! 结尾的类型表示破坏性变更
+	TypeMap map[string]string `yaml:"type_map"`
+}
+
+// ReleaseSection 是发布说明配置
+type ReleaseSection struct {
+	Template       string         `yaml:"template"`
+	IncludeAuthors bool           `yaml:"include_authors"`
+	IncludeLinks   bool           `yaml:"include_links"`
+	Groups         []ReleaseGroup `yaml:"groups"`
+}
+
+// ReleaseGroup 是发布说明中的一个变更分组
+type ReleaseGroup struct {
+	Title string   `yaml:"title"`
+	Types []string `yaml:"types"`
+}
+
+// CISection 是 CI/CD 配置
+type CISection struct {
+	ReleaseBranches    []string `yaml:"release_branches"`
+	PrereleaseBranches []string `yaml:"prerelease_branches"`
+	BumpCommitTemplate *string  `yaml:"bump_commit_template"`
+	// MaintenanceBranches 是维护分支的通配符，分支名称以 <major>.x 或 <major>.<minor>.x 结尾
+	MaintenanceBranches []string `yaml:"maintenance_branches"`
+}
+
+// ComponentSection 是 monorepo 中一个独立发布的组件的配置
+type ComponentSection struct {
+	Name string `yaml:"name"`
+	// Paths 是组件包含的目录或文件，相对于仓库根目录
+	Paths []string `yaml:"paths"`
+	// TagPrefix 是组件版本标签的前缀，例如 api/v 或 api@，默认为 <name>/v
+	TagPrefix  *string  `yaml:"tag_prefix"`
+	PatchTypes []string `yaml:"patch_types"`
+	MinorTypes []string `yaml:"minor_types"`
+	// Changelog 是组件的变更日志文件，默认为第一个路径下的 CHANGELOG.md
+	Changelog string `yaml:"changelog"`
+}
+
+// ChannelSection 是一个发布渠道的配置
+type ChannelSection struct {
+	Name string `yaml:"name"`
+	// Branches 是属于该渠道的分支，支持 *、? 和 ** 通配符
+	Branches []string `yaml:"branches"`
+	// PreRelease 是预发布版本第一个标识的模板，例如 beta 或 {{ .BranchSlug }}，为空时发布正式版本
+	PreRelease string `yaml:"prerelease"`
+}
+
+// Component 是合并默认值后的组件设置
+type Component struct {
+	Name       string
+	Paths      []string
+	TagPrefix  string
+	PatchTypes []string
+	MinorTypes []string
+	Changelog  string
+}
+
+// CISettings 是由 GitLab CI 预定义变量填充的选项
+type CISettings struct {
+	ProjectPath   string
+	ProjectURL    string
+	CommitRefName string
+	CommitSHA     string
+	// CommitTag 来自 --ci-commit-tag，命令没有这个选项时来自 CI_COMMIT_TAG
+	CommitTag string
+	// TagPipeline 表示在标签流水线中运行: CommitTag 或 CI_COMMIT_TAG 不为空。
+	// tag 和 release 的 --ci-commit-tag 是要创建的标签，没有指定时仍然通过 CI_COMMIT_TAG 识别标签流水线，
+	// 标签流水线中 CI_COMMIT_REF_NAME 是标签名称而不是分支
+	TagPipeline bool
+}
+
+// Settings 是合并配置文件、环境变量和命令行选项后的最终设置
+type Settings struct {
+	// ConfigFile 是实际加载的配置文件，没有找到时为空
+	ConfigFile string
+	// DryRun 为 true 时只打印执行计划，不修改 GitLab 中的数据
+	DryRun bool
+	// Output 是只读命令的输出格式，取值见 OutputFormats
+	Output string
+	// GitPush 为 true 时在本地仓库创建标签并推送，而不是通过 GitLab API 创建
+	GitPush bool
+	// SignKeyFile 是为标签签名的私钥文件，SignKey 是私钥内容，优先使用 SignKey
+	SignKeyFile string
+	SignKey     string
+	// SignKeyPassphrase 是加密私钥的密码
+	SignKeyPassphrase string
+	// Journal 是记录已完成操作的日志文件，为空时不记录
+	Journal string
+	// Rollback 为 true 时回滚日志中记录的操作，而不是继续执行
+	Rollback bool
+	// Timeout 是执行 GitLab 操作的总时间限制，0 表示不限制
+	Timeout time.Duration
+	// Retries 是操作失败后的最大重试次数
+	Retries int
+	// RetryMaxDelay 是两次重试之间的最长等待时间
+	RetryMaxDelay time.Duration
+
+	Token         string
+	APIURL        string
+	SkipSSLVerify bool
+
+	TagPrefix          string
+	InitialDevelopment bool
+	BumpPatch          bool
+	PreTmpl            []string
+	BuildTmpl          []string
+	// ReleaseAs 是 --release-as 指定的下一个版本，为空时根据提交计算
+	ReleaseAs string
+	// Scheme 是版本号方案，CalVerFormat 是 calver 方案使用的日历版本格式
+	Scheme       string
+	CalVerFormat string
+
+	PatchTypes  []string
+	MinorTypes  []string
+	IgnoreTypes []string
+	// MergeStrategy 是分析合并提交的方式
+	MergeStrategy domain.MergeStrategy
+	// Convention 是提交消息遵循的约定，ConventionPattern 和 ConventionTypes 来自配置文件
+	Convention        string
+	ConventionPattern string
+	ConventionTypes   map[string]string
+
+	ReleaseBranches    []string
+	PrereleaseBranches []string
+	BumpCommitTmpl     string
+	// MaintenanceBranches 是维护分支的通配符，维护分支只能发布分支名称中的版本范围内的版本
+	MaintenanceBranches []string
+	// Channels 是按顺序匹配分支的发布渠道
+	Channels []domain.Channel
+	// VersionFiles 是 commit-and-tag 在发布提交中更新版本号的文件
+	VersionFiles []domain.VersionFile
+
+	// Components 是配置文件中定义的组件
+	Components []Component
+	// ComponentNames 是 --component 选择的组件，AllComponents 为 true 时选择所有组件
+	ComponentNames []string
+	AllComponents  bool
+
+	Release ReleaseSection
+	CI      CISettings
+}
+
+// envAliases 是除 GSG_* 之外也被识别的环境变量，包括 GitLab CI 预定义变量。
+// CI 预定义变量和其他环境变量一样优先于配置文件
+var envAliases = map[string][]string{
+	"token":              {"GITLAB_TOKEN"},
+	"gl-api":             {"GITLAB_API_URL", "CI_API_V4_URL"},
+	"skip-ssl-verify":    {"GITLAB_SKIP_SSL_VERIFY"},
+	"ci-project-path":    {"CI_PROJECT_PATH"},
+	"ci-project-url":     {"CI_PROJECT_URL"},
+	"ci-commit-ref-name": {"CI_COMMIT_REF_NAME"},
+	"ci-commit-sha":      {"CI_COMMIT_SHA"},
+}
+
+// EnvNames 返回可以覆盖指定选项的环境变量，按优先级排列
+func EnvNames(flag string) []string {
+	name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+	return append([]string{name}, envAliases[flag]...)
+}
+
+// Find 查找配置文件。
+// 依次检查 explicit、当前目录和用户主目录，explicit 不为空但文件不存在时返回错误。
+// 没有找到配置文件时返回空字符串。
+func Find(explicit string) (string, error) {
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err != nil {
+			return "", errors.Wrapf(err, "配置文件 %s 不可用", explicit)
+		}
+		return explicit, nil
+	}
+
+	candidates := []string{FileName}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, FileName))
+	}
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c, nil
+		}
+	}
+	return "", nil
+}
+
+// Load 读取并校验配置文件
+func Load(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取配置文件失败")
+	}
+	file, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrapf(err, "配置文件 %s 无效", path)
+	}
+	return file, nil
+}
+
+// Parse 解析并校验 YAML 格式的配置，未知的配置项视为错误
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(file); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "解析 YAML 失败")
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Validate 校验配置文件的内容
+func (f *File) Validate() error {
+	if f.GitLab.APIURL != nil {
+		u, err := url.Parse(*f.GitLab.APIURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.Errorf("gitlab.api_url 不是有效的 URL: %q", *f.GitLab.APIURL)
+		}
+	}
+	if f.Version.TagPrefix != nil && strings.ContainsAny(*f.Version.TagPrefix, " \t\n") {
+		return errors.Errorf("version.tag_prefix 不能包含空白字符: %q", *f.Version.TagPrefix)
+	}
+	lists := map[string][]string{
+		"version.pre_templates":   f.Version.PreTemplates,
+		"version.build_templates": f.Version.BuildTemplates,
+		"commit.patch_types":      f.Commit.PatchTypes,
+		"commit.minor_types":      f.Commit.MinorTypes,
+		"commit.ignore_types":     f.Commit.IgnoreTypes,
+		"ci.release_branches":     f.CI.ReleaseBranches,
+		"ci.prerelease_branches":  f.CI.PrereleaseBranches,
+		"ci.maintenance_branches": f.CI.MaintenanceBranches,
+	}
+	for key, list := range lists {
+		for _, item := range list {
+			if strings.TrimSpace(item) == "" {
+				return errors.Errorf("%s 不能包含空值", key)
+			}
+			if strings.Contains(item, ",") {
+				return errors.Errorf("%s 的值不能包含逗号: %q", key, item)
+			}
+		}
+	}
+	if err := checkTypes(f.Commit.PatchTypes, f.Commit.MinorTypes); err != nil {
+		return err
+	}
+	if f.Version.Scheme != nil {
+		if _, err := domain.ParseVersionScheme(*f.Version.Scheme, domain.CalVerFormats[0]); err != nil {
+			return errors.Wrap(err, "version.scheme 无效")
+		}
+	}
+	if f.Version.CalVerFormat != nil {
+		if _, err := domain.NewCalVer(*f.Version.CalVerFormat); err != nil {
+			return errors.Wrap(err, "version.calver_format 无效")
+		}
+	}
+	if f.Commit.MergeStrategy != nil {
+		if _, err := domain.ParseMergeStrategy(*f.Commit.MergeStrategy); err != nil {
+			return errors.Wrap(err, "commit.merge_strategy 无效")
+		}
+	}
+	if f.Commit.Convention != nil || f.Commit.Pattern != "" || f.Commit.TypeMap != nil {
+		convention := ""
+		if f.Commit.Convention != nil {
+			convention = *f.Commit.Convention
+		}
+		if _, err := domain.NewCommitParser(convention, f.Commit.Pattern, f.Commit.TypeMap); err != nil {
+			return errors.Wrap(err, "commit.convention 无效")
+		}
+	}
+	if err := validateComponents(f.Components); err != nil {
+		return err
+	}
+	if err := validateChannels(f.Channels); err != nil {
+		return err
+	}
+	if _, err := resolveVersionFiles(f.Version.Files); err != nil {
+		return err
+	}
+	for i, g := range f.Release.Groups {
+		if strings.TrimSpace(g.Title) == "" {
+			return errors.Errorf("release.groups[%d].title 不能为空", i)
+		}
+		if len(g.Types) == 0 {
+			return errors.Errorf("release.groups[%d].types 不能为空", i)
+		}
+	}
+	return nil
+}
+
+// fileValues 返回配置文件中设置的选项，键为命令行选项名
+func (f *File) fileValues() map[string]string {
+	values := make(map[string]string)
+	if f.GitLab.APIURL != nil {
+		values["gl-api"] = *f.GitLab.APIURL
+	}
+	if f.GitLab.SkipSSLVerify != nil {
+		values["skip-ssl-verify"] = fmt.Sprint(*f.GitLab.SkipSSLVerify)
+	}
+	if f.Version.TagPrefix != nil {
+		values["tag-prefix"] = *f.Version.TagPrefix
+	}
+	if f.Version.InitialDevelopment != nil {
+		values["initial-development"] = fmt.Sprint(*f.Version.InitialDevelopment)
+	}
+	if f.Version.PreTemplates != nil {
+		values["pre-tmpl"] = strings.Join(f.Version.PreTemplates, ",")
+	}
+	if f.Version.BuildTemplates != nil {
+		values["build-tmpl"] = strings.Join(f.Version.BuildTemplates, ",")
+	}
+	if f.Version.Scheme != nil {
+		values["scheme"] = *f.Version.Scheme
+	}
+	if f.Version.CalVerFormat != nil {
+		values["calver-format"] = *f.Version.CalVerFormat
+	}
+	if f.Commit.PatchTypes != nil {
+		values["patch-commit-types"] = strings.Join(f.Commit.PatchTypes, ",")
+	}
+	if f.Commit.MinorTypes != nil {
+		values["minor-commit-types"] = strings.Join(f.Commit.MinorTypes, ",")
+	}
+	if f.Commit.MergeStrategy != nil {
+		values["merge-strategy"] = *f.Commit.MergeStrategy
+	}
+	if f.Commit.Convention != nil {
+		values["convention"] = *f.Commit.Convention
+	}
+	if f.CI.ReleaseBranches != nil {
+		values["release-branches"] = strings.Join(f.CI.ReleaseBranches, ",")
+	}
+	if f.CI.MaintenanceBranches != nil {
+		values["maintenance-branches"] = strings.Join(f.CI.MaintenanceBranches, ",")
+	}
+	if f.CI.BumpCommitTemplate != nil {
+		values["bump-commit-tmpl"] = *f.CI.BumpCommitTemplate
+	}
+	return values
+}
+
+// Resolve 合并配置来源并返回最终设置。
+// 优先级从高到低为：显式指定的命令行选项、环境变量、配置文件、选项默认值。
+// file 可以为 nil，lookupEnv 通常为 os.LookupEnv。
+func Resolve(flags *pflag.FlagSet, file *File, lookupEnv func(string) (string, bool)) (*Settings, error) {
+	if file == nil {
+		file = &File{}
+	}
+	values := file.fileValues()
+
+	var resolveErr error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if resolveErr != nil || flag.Changed {
+			return
+		}
+		for _, env := range EnvNames(flag.Name) {
+			if value, ok := lookupEnv(env); ok {
+				if err := flags.Set(flag.Name, value); err != nil {
+					resolveErr = errors.Wrapf(err, "环境变量 %s 无效", env)
+				}
+				return
+			}
+		}
+		if value, ok := values[flag.Name]; ok {
+			if err := flags.Set(flag.Name, value); err != nil {
+				resolveErr = errors.Wrapf(err, "配置项 %s 无效", flag.Name)
+			}
+		}
+	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	s := &Settings{
+		DryRun:             getBool(flags, "dry-run"),
+		Output:             getString(flags, "output"),
+		GitPush:            getBool(flags, "git-push"),
+		SignKeyFile:        getString(flags, "sign-key-file"),
+		SignKey:            getString(flags, "sign-key"),
+		SignKeyPassphrase:  getString(flags, "sign-key-passphrase"),
+		Journal:            getString(flags, "journal"),
+		Rollback:           getBool(flags, "rollback"),
+		Timeout:            getDuration(flags, "timeout"),
+		Retries:            getInt(flags, "retries"),
+		RetryMaxDelay:      getDuration(flags, "retry-max-delay"),
+		Token:              getString(flags, "token"),
+		APIURL:             getString(flags, "gl-api"),
+		SkipSSLVerify:      getBool(flags, "skip-ssl-verify"),
+		TagPrefix:          getString(flags, "tag-prefix"),
+		InitialDevelopment: getBool(flags, "initial-development"),
+		BumpPatch:          getBool(flags, "bump-patch"),
+		ReleaseAs:          getString(flags, "release-as"),
+		PreTmpl:            SplitList(getString(flags, "pre-tmpl")),
+		BuildTmpl:          SplitList(getString(flags, "build-tmpl")),
+		PatchTypes:         SplitList(getString(flags, "patch-commit-types")),
+		MinorTypes:         SplitList(getString(flags, "minor-commit-types")),
+		IgnoreTypes:        file.Commit.IgnoreTypes,
+		Convention:         getString(flags, "convention"),
+		ConventionPattern:  file.Commit.Pattern,
+		ConventionTypes:    file.Commit.TypeMap,
+		ReleaseBranches:    SplitList(getString(flags, "release-branches")),
+		PrereleaseBranches: file.CI.PrereleaseBranches,
+		BumpCommitTmpl:     getString(flags, "bump-commit-tmpl"),
+		ComponentNames:     SplitList(getString(flags, "component")),
+		AllComponents:      getBool(flags, "all-components"),
+		Release:            file.Release,
+		CI: CISettings{
+			ProjectPath:   getString(flags, "ci-project-path"),
+			ProjectURL:    getString(flags, "ci-project-url"),
+			CommitRefName: getString(flags, "ci-commit-ref-name"),
+			CommitSHA:     getString(flags, "ci-commit-sha"),
+			CommitTag:     getString(flags, "ci-commit-tag"),
+		},
+	}
+	ciCommitTag, _ := lookupEnv("CI_COMMIT_TAG")
+	if flags.Lookup("ci-commit-tag") == nil {
+		s.CI.CommitTag = ciCommitTag
+	}
+	s.CI.TagPipeline = s.CI.CommitTag != "" || ciCommitTag != ""
+	if err := checkTypes(s.PatchTypes, s.MinorTypes); err != nil {
+		return nil, err
+	}
+	for _, t := range s.IgnoreTypes {
+		if slices.Contains(s.PatchTypes, t) || slices.Contains(s.MinorTypes, t) {
+			return nil, errors.Errorf("提交类型 %s 不能同时被忽略和触发版本升级", t)
+		}
+	}
+	strategy, err := domain.ParseMergeStrategy(getString(flags, "merge-strategy"))
+	if err != nil {
+		return nil, errors.Wrap(err, "--merge-strategy 无效")
+	}
+	s.MergeStrategy = strategy
+	s.Scheme = getString(flags, "scheme")
+	s.CalVerFormat = getString(flags, "calver-format")
+	scheme, err := s.VersionScheme()
+	if err != nil {
+		return nil, errors.Wrap(err, "--scheme 无效")
+	}
+	if s.ReleaseAs != "" {
+		if _, err := scheme.Parse(strings.TrimPrefix(s.ReleaseAs, "v")); err != nil {
+			return nil, errors.Wrapf(err, "--release-as 不是有效的版本: %q", s.ReleaseAs)
+		}
+	}
+	if _, err := s.CommitParser(); err != nil {
+		return nil, errors.Wrap(err, "--convention 无效")
+	}
+	if _, err := render.BumpMessage("v1.0.0", "1.0.0", s.BumpCommitTmpl); err != nil {
+		return nil, errors.Wrap(err, "--bump-commit-tmpl 无效")
+	}
+	if s.Components, err = resolveComponents(file.Components, s.PatchTypes, s.MinorTypes); err != nil {
+		return nil, err
+	}
+	if _, err := s.SelectedComponents(); err != nil {
+		return nil, err
+	}
+	s.MaintenanceBranches = SplitList(getString(flags, "maintenance-branches"))
+	s.Channels = resolveChannels(file.Channels, s.ReleaseBranches, s.PrereleaseBranches)
+	if s.VersionFiles, err = resolveVersionFiles(file.Version.Files); err != nil {
+		return nil, err
+	}
+	if paths := SplitList(getString(flags, "version-files")); len(paths) > 0 {
+		// 命令行选项中的文件根据文件名推断类型，替换配置文件中的版本文件
+		s.VersionFiles = make([]domain.VersionFile, len(paths))
+		for i, p := range paths {
+			if s.VersionFiles[i], err = domain.NewVersionFile(p, "", "", ""); err != nil {
+				return nil, errors.Wrap(err, "--version-files 无效")
+			}
+		}
+	}
+	if err := checkOutput(s.Output); err != nil {
+		return nil, err
+	}
+	if s.Timeout < 0 {
+		return nil, errors.New("--timeout 不能为负数")
+	}
+	if s.Retries < 0 {
+		return nil, errors.New("--retries 不能为负数")
+	}
+	if s.Rollback && s.Journal == "" {
+		return nil, errors.New("--rollback 需要通过 --journal 指定日志文件")
+	}
+	return s, nil
+}
+
+// FromFlags 根据命令行选项 --config 查找并加载配置文件，再与环境变量和命令行选项合并
+func FromFlags(flags *pflag.FlagSet, lookupEnv func(string) (string, bool)) (*Settings, error) {
+	explicit := getString(flags, "config")
+	if f := flags.Lookup("config"); f == nil || !f.Changed {
+		if env, ok := lookupEnv(EnvNames("config")[0]); ok {
+			explicit = env
+		}
+	}
+
+	path, err := Find(explicit)
+	if err != nil {
+		return nil, err
+	}
+
+	var file *File
+	if path != "" {
+		if file, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := Resolve(flags, file, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	s.ConfigFile = path
+	return s, nil
+}
+
+// BumpOptions 返回版本计算策略
+func (s *Settings) BumpOptions() domain.BumpOptions {
+	return domain.BumpOptions{
+		InitialDevelopment: s.InitialDevelopment,
+		BumpPatch:          s.BumpPatch,
+	}
+}
+
+// NoteGroups 返回配置文件中 release.groups 定义的发布说明分组，没有定义时返回空列表
+func (s *Settings) NoteGroups() []domain.NoteGroup {
+	groups := make([]domain.NoteGroup, len(s.Release.Groups))
+	for i, g := range s.Release.Groups {
+		groups[i] = domain.NoteGroup{Title: g.Title, Types: g.Types}
+	}
+	return groups
+}
+
+// VersionScheme 返回设置的版本号方案
+func (s *Settings) VersionScheme() (domain.VersionScheme, error) {
+	return domain.ParseVersionScheme(s.Scheme, s.CalVerFormat)
+}
+
+// CommitParser 返回按设置的提交约定解析提交消息的解析器。
+// 配置文件中的正则表达式只在选择 regex 约定时使用，因此可以用 --convention 临时切换到其他约定
+func (s *Settings) CommitParser() (domain.CommitParser, error) {
+	pattern := ""
+	if domain.Convention(s.Convention) == domain.ConventionRegex {
+		pattern = s.ConventionPattern
+	}
+	return domain.NewCommitParser(s.Convention, pattern, s.ConventionTypes)
+}
+
+// RetryPolicy 返回执行 GitLab 操作时使用的重试策略
+func (s *Settings) RetryPolicy() workflow.RetryPolicy {
+	policy := workflow.DefaultRetryPolicy
+	policy.MaxRetries = s.Retries
+	if s.RetryMaxDelay > 0 {
+		policy.MaxDelay = s.RetryMaxDelay
+	}
+	return policy
+}
+
+// SelectedComponents 返回 --component 或 --all-components 选择的组件，没有选择组件时返回空列表
+func (s *Settings) SelectedComponents() ([]Component, error) {
+	if s.AllComponents {
+		if len(s.ComponentNames) > 0 {
+			return nil, errors.New("--component 和 --all-components 不能同时使用")
+		}
+		if len(s.Components) == 0 {
+			return nil, errors.New("--all-components 需要在配置文件中定义 components")
+		}
+		return s.Components, nil
+	}
+
+	selected := make([]Component, 0, len(s.ComponentNames))
+	for _, name := range s.ComponentNames {
+		found := false
+		for _, c := range s.Components {
+			if c.Name == name {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("配置文件中没有定义组件 %s", name)
+		}
+	}
+	return selected, nil
+}
+
+// Channel 返回分支所属的发布渠道，分支不属于任何渠道时返回错误
+func (s *Settings) Channel(branch string) (domain.Channel, error) {
+	channel, ok := domain.FindChannel(s.Channels, branch)
+	if !ok {
+		return domain.Channel{}, errors.Errorf("分支 %s 不属于任何发布渠道，不能发布版本", branch)
+	}
+	return channel, nil
+}
+
+// Maintenance 返回维护分支允许的版本范围，分支不是维护分支时返回 false。
+// 分支匹配维护分支的通配符但名称中没有版本范围时返回错误
+func (s *Settings) Maintenance(branch string) (domain.MaintenanceRange, bool, error) {
+	if !domain.MatchBranch(s.MaintenanceBranches, branch) {
+		return domain.MaintenanceRange{}, false, nil
+	}
+	r, err := domain.ParseMaintenanceBranch(branch)
+	if err != nil {
+		return domain.MaintenanceRange{}, false, err
+	}
+	return r, true, nil
+}
+
+// RequireGitLab 检查访问 GitLab API 所需的设置
+func (s *Settings) RequireGitLab() error {
+	if s.Token == "" {
+		return errors.New("必须提供 GitLab 访问令牌 (--token 或 GSG_TOKEN)")
+	}
+	if s.APIURL == "" {
+		return errors.New("必须提供 GitLab API URL (--gl-api 或 CI_API_V4_URL)")
+	}
+	if s.CI.ProjectPath == "" {
+		return errors.New("必须提供项目路径 (--ci-project-path 或 CI_PROJECT_PATH)")
+	}
+	return nil
+}
+
+// ProjectURL 返回解析后的项目 URL
+func (s *Settings) ProjectURL() (*url.URL, error) {
+	if s.CI.ProjectURL == "" {
+		return nil, errors.New("必须提供项目 URL (--ci-project-url 或 CI_PROJECT_URL)")
+	}
+	u, err := url.Parse(s.CI.ProjectURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析 project-url 失败")
+	}
+	return u, nil
+}
+
+// SplitList 把逗号分隔的字符串拆分为去除空白的非空元素列表
+func SplitList(s string) []string {
+	rv := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			rv = append(rv, item)
+		}
+	}
+	return rv
+}
+
+// validateComponents 校验组件的名称、路径和标签前缀
+func validateComponents(components []ComponentSection) error {
+	names := make(map[string]bool)
+	prefixes := make(map[string]string)
+	for i, c := range components {
+		if strings.TrimSpace(c.Name) == "" {
+			return errors.Errorf("components[%d].name 不能为空", i)
+		}
+		if strings.ContainsAny(c.Name, " \t\n,") {
+			return errors.Errorf("components[%d].name 不能包含空白字符或逗号: %q", i, c.Name)
+		}
+		if names[c.Name] {
+			return errors.Errorf("组件 %s 重复定义", c.Name)
+		}
+		names[c.Name] = true
+
+		if len(c.Paths) == 0 {
+			return errors.Errorf("组件 %s 的 paths 不能为空", c.Name)
+		}
+		for _, p := range c.Paths {
+			if strings.TrimSpace(p) == "" {
+				return errors.Errorf("组件 %s 的 paths 不能包含空值", c.Name)
+			}
+		}
+
+		prefix := c.Name + "/v"
+		if c.TagPrefix != nil {
+			prefix = *c.TagPrefix
+		}
+		if prefix == "" || strings.ContainsAny(prefix, " \t\n") {
+			return errors.Errorf("组件 %s 的 tag_prefix 不能为空或包含空白字符: %q", c.Name, prefix)
+		}
+		if other, ok := prefixes[prefix]; ok {
+			return errors.Errorf("组件 %s 和 %s 使用相同的标签前缀 %s", other, c.Name, prefix)
+		}
+		prefixes[prefix] = c.Name
+
+		if err := checkTypes(c.PatchTypes, c.MinorTypes); err != nil {
+			return errors.Wrapf(err, "组件 %s 无效", c.Name)
+		}
+	}
+	return nil
+}
+
+// resolveVersionFiles 校验版本文件的配置并返回版本文件
+func resolveVersionFiles(sections []VersionFileSection) ([]domain.VersionFile, error) {
+	files := make([]domain.VersionFile, 0, len(sections))
+	paths := make(map[string]bool)
+	for i, section := range sections {
+		f, err := domain.NewVersionFile(section.Path, domain.VersionFileType(section.Type), section.Pattern, section.Marker)
+		if err != nil {
+			return nil, errors.Wrapf(err, "version.files[%d] 无效", i)
+		}
+		if paths[f.Path] {
+			return nil, errors.Errorf("版本文件 %s 重复定义", f.Path)
+		}
+		paths[f.Path] = true
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// validateChannels 校验发布渠道的名称、分支和预发布版本模板
+func validateChannels(channels []ChannelSection) error {
+	names := make(map[string]bool)
+	for i, c := range channels {
+		if strings.TrimSpace(c.Name) == "" {
+			return errors.Errorf("channels[%d].name 不能为空", i)
+		}
+		if names[c.Name] {
+			return errors.Errorf("发布渠道 %s 重复定义", c.Name)
+		}
+		names[c.Name] = true
+
+		if len(c.Branches) == 0 {
+			return errors.Errorf("发布渠道 %s 的 branches 不能为空", c.Name)
+		}
+		for _, b := range c.Branches {
+			if strings.TrimSpace(b) == "" {
+				return errors.Errorf("发布渠道 %s 的 branches 不能包含空值", c.Name)
+			}
+		}
+		if _, err := template.New(c.Name).Parse(c.PreRelease); err != nil {
+			return errors.Wrapf(err, "发布渠道 %s 的 prerelease 模板无效", c.Name)
+		}
+	}
+	return nil
+}
+
+// DefaultChannels 返回没有配置发布渠道时使用的渠道：releaseBranches 发布正式版本，
+// prereleaseBranches 中的分支、next 和 beta 分支以分支名称作为预发布版本标识，
+// 其他分支以 CI_COMMIT_REF_SLUG 规则生成的分支名称作为预发布版本标识
+func DefaultChannels(releaseBranches, prereleaseBranches []string) []domain.Channel {
+	channels := []domain.Channel{{Name: "stable", Branches: releaseBranches}}
+	for _, b := range prereleaseBranches {
+		channels = append(channels, domain.Channel{Name: b, Branches: []string{b}, PreRelease: domain.BranchSlug(b)})
+	}
+	return append(channels,
+		domain.Channel{Name: "next", Branches: []string{"next"}, PreRelease: "next"},
+		domain.Channel{Name: "beta", Branches: []string{"beta"}, PreRelease: "beta"},
+		domain.Channel{Name: "feature", Branches: []string{"**"}, PreRelease: "{{ .BranchSlug }}"},
+	)
+}
+
+// resolveChannels 返回配置文件中的发布渠道，没有配置时返回 DefaultChannels
+func resolveChannels(sections []ChannelSection, releaseBranches, prereleaseBranches []string) []domain.Channel {
+	if len(sections) == 0 {
+		return DefaultChannels(releaseBranches, prereleaseBranches)
+	}
+	channels := make([]domain.Channel, 0, len(sections))
+	for _, c := range sections {
+		channels = append(channels, domain.Channel{Name: c.Name, Branches: c.Branches, PreRelease: c.PreRelease})
+	}
+	return channels
+}
+
+// resolveComponents 为组件设置默认的标签前缀、提交类型和变更日志文件
+func resolveComponents(sections []ComponentSection, patchTypes, minorTypes []string) ([]Component, error) {
+	components := make([]Component, 0, len(sections))
+	for _, c := range sections {
+		component := Component{
+			Name:       c.Name,
+			Paths:      c.Paths,
+			TagPrefix:  c.Name + "/v",
+			PatchTypes: patchTypes,
+			MinorTypes: minorTypes,
+			Changelog:  c.Changelog,
+		}
+		if c.TagPrefix != nil {
+			component.TagPrefix = *c.TagPrefix
+		}
+		if c.PatchTypes != nil {
+			component.PatchTypes = c.PatchTypes
+		}
+		if c.MinorTypes != nil {
+			component.MinorTypes = c.MinorTypes
+		}
+		if component.Changelog == "" && len(c.Paths) > 0 {
+			component.Changelog = path.Join(c.Paths[0], "CHANGELOG.md")
+		}
+		if err := checkTypes(component.PatchTypes, component.MinorTypes); err != nil {
+			return nil, errors.Wrapf(err, "组件 %s 无效", c.Name)
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+// checkOutput 检查输出格式是否受支持，空字符串表示默认的 text
+func checkOutput(output string) error {
+	if output == "" {
+		return nil
+	}
+	for _, f := range OutputFormats {
+		if output == f {
+			return nil
+		}
+	}
+	return errors.Errorf("--output 无效: 未知的输出格式 %q，可选值为 %s", output, strings.Join(OutputFormats, ", "))
+}
+
+// checkTypes 检查同一个提交类型没有同时出现在补丁和次要版本类型中
+func checkTypes(patchTypes, minorTypes []string) error {
+	for _, p := range patchTypes {
+		for _, m := range minorTypes {
+			if p == m {
+				return errors.Errorf("提交类型 %s 不能同时属于补丁版本和次要版本", p)
+			}
+		}
+	}
+	return nil
+}
+
+func getString(flags *pflag.FlagSet, name string) string {
+	if flags.Lookup(name) == nil {
+		return ""
+	}
+	value, _ := flags.GetString(name)
+	return value
+}
+
+func getInt(flags *pflag.FlagSet, name string) int {
+	if flags.Lookup(name) == nil {
+		return 0
+	}
+	value, _ := flags.GetInt(name)
+	return value
+}
+
+func getDuration(flags *pflag.FlagSet, name string) time.Duration {
+	if flags.Lookup(name) == nil {
+		return 0
+	}
+	value, _ := flags.GetDuration(name)
+	return value
+}
+
+func getBool(flags *pflag.FlagSet, name string) bool {
+	if flags.Lookup(name) == nil {
+		return false
+	}
+	value, _ := flags.GetBool(name)
+	return value
+}
@@ -0,0 +1,570 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFlags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("config", "", "")
+	flags.String("output", "text", "")
+	flags.String("token", "", "")
+	flags.String("gl-api", "", "")
+	flags.Bool("skip-ssl-verify", false, "")
+	flags.String("patch-commit-types", "fix,refactor", "")
+	flags.String("minor-commit-types", "feat", "")
+	flags.String("merge-strategy", "title", "")
+	flags.String("convention", "conventional", "")
+	flags.Bool("initial-development", true, "")
+	flags.Bool("bump-patch", false, "")
+	flags.String("release-as", "", "")
+	flags.String("release-branches", "main,master", "")
+	flags.String("maintenance-branches", "*.x,release/*.x", "")
+	flags.String("tag-prefix", "v", "")
+	flags.String("scheme", "semver", "")
+	flags.String("calver-format", "YYYY.MM.MICRO", "")
+	flags.String("bump-commit-tmpl", "chore: {{tag}}", "")
+	flags.String("pre-tmpl", "", "")
+	flags.String("build-tmpl", "", "")
+	flags.String("ci-project-path", "", "")
+	flags.String("component", "", "")
+	flags.Bool("all-components", false, "")
+	flags.String("version-files", "", "")
+	return flags
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+const sample = `
+gitlab:
+  api_url: https://gitlab.example.com/api/v4
+  skip_ssl_verify: true
+version:
+  tag_prefix: release-
+  initial_development: false
+  pre_templates: [rc, "{{ seq }}"]
+commit:
+  patch_types: [fix]
+  minor_types: [feat, perf]
+  ignore_types: [chore]
+  merge_strategy: branch
+release:
+  include_links: true
+  groups:
+    - title: Features
+      types: [feat]
+ci:
+  release_branches: [main]
+  prerelease_branches: [develop]
+  bump_commit_template: "chore: release {{tag}}"
+`
+
+func TestParse(t *testing.T) {
+	file, err := Parse(strings.NewReader(sample))
+	require.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com/api/v4", *file.GitLab.APIURL)
+	assert.Equal(t, []string{"feat", "perf"}, file.Commit.MinorTypes)
+	assert.Equal(t, "Features", file.Release.Groups[0].Title)
+
+	empty, err := Parse(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Nil(t, empty.Version.TagPrefix)
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown key", "gitlab:\n  token: secret\n"},
+		{"unknown section", "foo: bar\n"},
+		{"wrong type", "gitlab:\n  skip_ssl_verify: maybe\n"},
+		{"invalid url", "gitlab:\n  api_url: not-a-url\n"},
+		{"empty type", "commit:\n  patch_types: [fix, \"\"]\n"},
+		{"type in both lists", "commit:\n  patch_types: [fix]\n  minor_types: [fix]\n"},
+		{"group without types", "release:\n  groups:\n    - title: x\n"},
+		{"prefix with space", "version:\n  tag_prefix: \"v \"\n"},
+		{"unknown merge strategy", "commit:\n  merge_strategy: squash\n"},
+		{"unknown convention", "commit:\n  convention: emoji\n"},
+		{"regex without pattern", "commit:\n  convention: regex\n"},
+		{"pattern without type group", "commit:\n  convention: regex\n  pattern: \"^(?P<subject>.+)$\"\n"},
+		{"pattern for preset", "commit:\n  convention: gitmoji\n  pattern: \"^(?P<type>\\\\w+) (?P<subject>.+)$\"\n"},
+		{"empty mapped type", "commit:\n  type_map:\n    feature: \"\"\n"},
+		{"component without name", "components:\n  - paths: [api]\n"},
+		{"component without paths", "components:\n  - name: api\n"},
+		{"duplicate component", "components:\n  - name: api\n    paths: [api]\n  - name: api\n    paths: [web]\n"},
+		{"duplicate tag prefix", "components:\n  - name: api\n    paths: [api]\n    tag_prefix: v\n  - name: web\n    paths: [web]\n    tag_prefix: v\n"},
+		{"channel without name", "channels:\n  - branches: [main]\n"},
+		{"channel without branches", "channels:\n  - name: beta\n    prerelease: beta\n"},
+		{"duplicate channel", "channels:\n  - name: beta\n    branches: [beta]\n  - name: beta\n    branches: [next]\n"},
+		{"invalid prerelease template", "channels:\n  - name: beta\n    branches: [beta]\n    prerelease: \"{{ .Branch\"\n"},
+		{"unknown version scheme", "version:\n  scheme: romver\n"},
+		{"version file type not inferred", "version:\n  files:\n    - path: README.md\n"},
+		{"regex version file without group", "version:\n  files:\n    - path: deploy.yml\n      type: regex\n      pattern: \"app:(\\\\S+)\"\n"},
+		{"duplicate version file", "version:\n  files:\n    - path: VERSION\n    - path: VERSION\n"},
+		{"invalid calver format", "version:\n  scheme: calver\n  calver_format: YYYY.DD.MICRO\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	file, err := Parse(strings.NewReader(sample))
+	require.NoError(t, err)
+
+	flags := newFlags()
+	require.NoError(t, flags.Parse([]string{"--tag-prefix", "cli-"}))
+
+	s, err := Resolve(flags, file, env(map[string]string{
+		"GSG_TOKEN":              "env-token",
+		"GSG_PATCH_COMMIT_TYPES": "fix,docs",
+		"GSG_TAG_PREFIX":         "env-",
+	}))
+	require.NoError(t, err)
+
+	// 命令行选项优先于环境变量
+	assert.Equal(t, "cli-", s.TagPrefix)
+	// 环境变量优先于配置文件
+	assert.Equal(t, []string{"fix", "docs"}, s.PatchTypes)
+	assert.Equal(t, "env-token", s.Token)
+	// 配置文件优先于默认值
+	assert.Equal(t, []string{"feat", "perf"}, s.MinorTypes)
+	assert.Equal(t, "https://gitlab.example.com/api/v4", s.APIURL)
+	assert.True(t, s.SkipSSLVerify)
+	assert.False(t, s.InitialDevelopment)
+	assert.Equal(t, []string{"rc", "{{ seq }}"}, s.PreTmpl)
+	assert.Equal(t, []string{"main"}, s.ReleaseBranches)
+	assert.Equal(t, []string{"develop"}, s.PrereleaseBranches)
+	assert.Equal(t, []string{"chore"}, s.IgnoreTypes)
+	assert.Equal(t, domain.MergeBranch, s.MergeStrategy)
+	assert.Equal(t, "chore: release {{tag}}", s.BumpCommitTmpl)
+	assert.True(t, s.Release.IncludeLinks)
+	assert.Equal(t, []domain.NoteGroup{{Title: "Features", Types: []string{"feat"}}}, s.NoteGroups())
+	// 未设置的选项使用默认值
+	assert.False(t, s.BumpPatch)
+	assert.Empty(t, s.BuildTmpl)
+}
+
+func TestResolveEnvAliases(t *testing.T) {
+	s, err := Resolve(newFlags(), nil, env(map[string]string{
+		"GITLAB_TOKEN":           "alias-token",
+		"GITLAB_SKIP_SSL_VERIFY": "true",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "alias-token", s.Token)
+	assert.True(t, s.SkipSSLVerify)
+
+	s, err = Resolve(newFlags(), nil, env(map[string]string{
+		"GITLAB_TOKEN": "alias-token",
+		"GSG_TOKEN":    "gsg-token",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "gsg-token", s.Token)
+}
+
+func TestResolveCIVariables(t *testing.T) {
+	file, err := Parse(strings.NewReader(sample))
+	require.NoError(t, err)
+	vars := map[string]string{
+		"CI_API_V4_URL":   "https://ci.example.com/api/v4",
+		"CI_PROJECT_PATH": "group/project",
+	}
+
+	// GitLab CI 预定义变量属于环境变量，优先于配置文件
+	s, err := Resolve(newFlags(), file, env(vars))
+	require.NoError(t, err)
+	assert.Equal(t, "https://ci.example.com/api/v4", s.APIURL)
+	assert.Equal(t, "group/project", s.CI.ProjectPath)
+
+	vars["GITLAB_API_URL"] = "https://alias.example.com/api/v4"
+	s, err = Resolve(newFlags(), file, env(vars))
+	require.NoError(t, err)
+	assert.Equal(t, "https://alias.example.com/api/v4", s.APIURL)
+
+	flags := newFlags()
+	require.NoError(t, flags.Set("gl-api", "https://flag.example.com/api/v4"))
+	s, err = Resolve(flags, file, env(vars))
+	require.NoError(t, err)
+	assert.Equal(t, "https://flag.example.com/api/v4", s.APIURL)
+}
+
+func TestResolveCommitTag(t *testing.T) {
+	vars := map[string]string{"CI_COMMIT_TAG": "v1.2.0"}
+	s, err := Resolve(newFlags(), nil, env(vars))
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", s.CI.CommitTag)
+
+	// 命令的 --ci-commit-tag 选项优先，没有指定时不使用 CI_COMMIT_TAG
+	flags := newFlags()
+	flags.String("ci-commit-tag", "", "")
+	s, err = Resolve(flags, nil, env(vars))
+	require.NoError(t, err)
+	assert.Empty(t, s.CI.CommitTag)
+
+	flags = newFlags()
+	flags.String("ci-commit-tag", "", "")
+	require.NoError(t, flags.Set("ci-commit-tag", "v2.0.0"))
+	s, err = Resolve(flags, nil, env(vars))
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0", s.CI.CommitTag)
+}
+
+func TestResolveTagPipeline(t *testing.T) {
+	// 标签流水线中 CI_COMMIT_REF_NAME 是标签名称
+	vars := map[string]string{"CI_COMMIT_REF_NAME": "v1.2.3", "CI_COMMIT_TAG": "v1.2.3"}
+	tests := []struct {
+		name        string
+		commandFlag bool
+		vars        map[string]string
+		tagPipeline bool
+	}{
+		{"tag pipeline", false, vars, true},
+		{"tag pipeline with --ci-commit-tag option", true, vars, true},
+		{"branch pipeline", true, map[string]string{"CI_COMMIT_REF_NAME": "main"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := newFlags()
+			flags.String("ci-commit-ref-name", tt.vars["CI_COMMIT_REF_NAME"], "")
+			if tt.commandFlag {
+				flags.String("ci-commit-tag", "", "")
+			}
+			s, err := Resolve(flags, nil, env(tt.vars))
+			require.NoError(t, err)
+			assert.Equal(t, tt.tagPipeline, s.CI.TagPipeline)
+			assert.Equal(t, tt.vars["CI_COMMIT_REF_NAME"], s.CI.CommitRefName)
+		})
+	}
+}
+
+func TestResolveInvalid(t *testing.T) {
+	_, err := Resolve(newFlags(), nil, env(map[string]string{"GSG_BUMP_PATCH": "sometimes"}))
+	assert.Error(t, err)
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_MINOR_COMMIT_TYPES": "feat,fix"}))
+	assert.Error(t, err)
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_MERGE_STRATEGY": "squash"}))
+	assert.Error(t, err)
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_CONVENTION": "regex"}))
+	assert.Error(t, err)
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_RELEASE_AS": "2.0"}))
+	assert.Error(t, err)
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_OUTPUT": "xml"}))
+	assert.Error(t, err)
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_SCHEME": "calver", "GSG_RELEASE_AS": "1.2.3"}))
+	assert.Error(t, err)
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_BUMP_COMMIT_TMPL": "chore: {{.NextVersion}}"}))
+	assert.Error(t, err)
+
+	file, err := Parse(strings.NewReader("commit:\n  ignore_types: [chore, fix]\n"))
+	require.NoError(t, err)
+	_, err = Resolve(newFlags(), file, env(nil))
+	assert.Error(t, err)
+}
+
+func TestResolveScheme(t *testing.T) {
+	s, err := Resolve(newFlags(), nil, env(nil))
+	require.NoError(t, err)
+	scheme, err := s.VersionScheme()
+	require.NoError(t, err)
+	assert.Equal(t, domain.SemVer{}, scheme)
+
+	file, err := Parse(strings.NewReader("version:\n  scheme: calver\n  calver_format: YY.0M.MICRO\n"))
+	require.NoError(t, err)
+	s, err = Resolve(newFlags(), file, env(map[string]string{"GSG_RELEASE_AS": "v26.01.0"}))
+	require.NoError(t, err)
+	assert.Equal(t, "calver", s.Scheme)
+	assert.Equal(t, "YY.0M.MICRO", s.CalVerFormat)
+	scheme, err = s.VersionScheme()
+	require.NoError(t, err)
+	v, err := scheme.Parse("26.01.0")
+	require.NoError(t, err)
+	assert.Equal(t, "26.01.0", scheme.Format(v))
+
+	// 命令行选项优先于配置文件
+	flags := newFlags()
+	require.NoError(t, flags.Parse([]string{"--scheme", "semver"}))
+	s, err = Resolve(flags, file, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "semver", s.Scheme)
+}
+
+const regexConvention = `
+commit:
+  convention: regex
+  pattern: "^(?P<type>[A-Z]+)-(?P<subject>.+)$"
+  type_map:
+    NEW: feat
+    BREAK: feat!
+`
+
+func TestResolveConvention(t *testing.T) {
+	file, err := Parse(strings.NewReader(regexConvention))
+	require.NoError(t, err)
+
+	s, err := Resolve(newFlags(), file, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "regex", s.Convention)
+	parser, err := s.CommitParser()
+	require.NoError(t, err)
+	c := parser.Parse("", "BREAK-drop v1 api")
+	assert.Equal(t, domain.TypeFeat, c.Type)
+	assert.True(t, c.Breaking)
+
+	// 命令行选项切换到其他约定时不使用配置文件中的正则表达式
+	flags := newFlags()
+	require.NoError(t, flags.Parse([]string{"--convention", "gitmoji"}))
+	s, err = Resolve(flags, file, env(nil))
+	require.NoError(t, err)
+	parser, err = s.CommitParser()
+	require.NoError(t, err)
+	assert.Equal(t, domain.TypeFeat, parser.Parse("", ":sparkles: add login").Type)
+}
+
+func TestResolveChannels(t *testing.T) {
+	// 没有配置发布渠道时使用 --release-branches 和 ci.prerelease_branches 生成默认渠道
+	file, err := Parse(strings.NewReader("ci:\n  prerelease_branches: [release/rc]\n"))
+	require.NoError(t, err)
+	flags := newFlags()
+	require.NoError(t, flags.Parse([]string{"--release-branches", "trunk"}))
+	s, err := Resolve(flags, file, env(nil))
+	require.NoError(t, err)
+
+	tests := []struct {
+		branch     string
+		name       string
+		prerelease string
+	}{
+		{"trunk", "stable", ""},
+		{"release/rc", "release/rc", "release-rc"},
+		{"next", "next", "next"},
+		{"beta", "beta", "beta"},
+		{"feature/login", "feature", "{{ .BranchSlug }}"},
+	}
+	for _, tt := range tests {
+		channel, err := s.Channel(tt.branch)
+		require.NoError(t, err)
+		assert.Equal(t, tt.name, channel.Name, tt.branch)
+		assert.Equal(t, tt.prerelease, channel.PreRelease, tt.branch)
+	}
+
+	// 配置的渠道代替默认渠道，不属于任何渠道的分支不能发布
+	file, err = Parse(strings.NewReader("channels:\n  - name: stable\n    branches: [main]\n  - name: qa\n    branches: [\"qa/*\"]\n    prerelease: qa\n"))
+	require.NoError(t, err)
+	s, err = Resolve(newFlags(), file, env(nil))
+	require.NoError(t, err)
+	channel, err := s.Channel("qa/sprint-3")
+	require.NoError(t, err)
+	assert.Equal(t, "qa", channel.Name)
+	_, err = s.Channel("feature/login")
+	assert.Error(t, err)
+}
+
+func TestSettingsMaintenance(t *testing.T) {
+	s, err := Resolve(newFlags(), nil, env(nil))
+	require.NoError(t, err)
+
+	tests := []struct {
+		branch      string
+		maintenance bool
+		want        string
+		err         bool
+	}{
+		{branch: "1.x", maintenance: true, want: "1.x"},
+		{branch: "release/1.2.x", maintenance: true, want: "1.2.x"},
+		{branch: "main"},
+		{branch: "feature/docs.x"},
+		{branch: "docs.x", err: true},
+	}
+	for _, tt := range tests {
+		r, ok, err := s.Maintenance(tt.branch)
+		if tt.err {
+			assert.Error(t, err, tt.branch)
+			continue
+		}
+		require.NoError(t, err, tt.branch)
+		assert.Equal(t, tt.maintenance, ok, tt.branch)
+		if ok {
+			assert.Equal(t, tt.want, r.String())
+		}
+	}
+
+	file, err := Parse(strings.NewReader("ci:\n  maintenance_branches: [\"support/*\"]\n"))
+	require.NoError(t, err)
+	s, err = Resolve(newFlags(), file, env(nil))
+	require.NoError(t, err)
+	_, ok, err := s.Maintenance("1.x")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	r, ok, err := s.Maintenance("support/2.x")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2.x", r.String())
+}
+
+func TestFromFlags(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "custom.yml")
+	require.NoError(t, os.WriteFile(path, []byte("version:\n  tag_prefix: custom-\n"), 0644))
+
+	flags := newFlags()
+	require.NoError(t, flags.Parse([]string{"--config", path}))
+	s, err := FromFlags(flags, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, path, s.ConfigFile)
+	assert.Equal(t, "custom-", s.TagPrefix)
+
+	flags = newFlags()
+	s, err = FromFlags(flags, env(map[string]string{"GSG_CONFIG": path}))
+	require.NoError(t, err)
+	assert.Equal(t, "custom-", s.TagPrefix)
+
+	flags = newFlags()
+	require.NoError(t, flags.Parse([]string{"--config", filepath.Join(dir, "missing.yml")}))
+	_, err = FromFlags(flags, env(nil))
+	assert.Error(t, err)
+}
+
+func TestSettingsRequireGitLab(t *testing.T) {
+	s := &Settings{}
+	assert.Error(t, s.RequireGitLab())
+	s.Token = "t"
+	s.APIURL = "https://gitlab.example.com"
+	assert.Error(t, s.RequireGitLab())
+	s.CI.ProjectPath = "group/project"
+	assert.NoError(t, s.RequireGitLab())
+}
+
+const components = `
+commit:
+  patch_types: [fix]
+  minor_types: [feat]
+components:
+  - name: api
+    paths: [services/api/, libs/common]
+  - name: web
+    paths: [web]
+    tag_prefix: web@
+    minor_types: [feat, perf]
+    changelog: docs/web-changelog.md
+`
+
+func TestResolveComponents(t *testing.T) {
+	file, err := Parse(strings.NewReader(components))
+	require.NoError(t, err)
+
+	s, err := Resolve(newFlags(), file, env(nil))
+	require.NoError(t, err)
+	assert.Equal(t, []Component{
+		{
+			Name:       "api",
+			Paths:      []string{"services/api/", "libs/common"},
+			TagPrefix:  "api/v",
+			PatchTypes: []string{"fix"},
+			MinorTypes: []string{"feat"},
+			Changelog:  "services/api/CHANGELOG.md",
+		},
+		{
+			Name:       "web",
+			Paths:      []string{"web"},
+			TagPrefix:  "web@",
+			PatchTypes: []string{"fix"},
+			MinorTypes: []string{"feat", "perf"},
+			Changelog:  "docs/web-changelog.md",
+		},
+	}, s.Components)
+
+	// 没有选择组件时分析整个仓库
+	selected, err := s.SelectedComponents()
+	require.NoError(t, err)
+	assert.Empty(t, selected)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{"one component", map[string]string{"GSG_COMPONENT": "web"}, []string{"web"}, false},
+		{"component list", map[string]string{"GSG_COMPONENT": "web,api"}, []string{"web", "api"}, false},
+		{"all components", map[string]string{"GSG_ALL_COMPONENTS": "true"}, []string{"api", "web"}, false},
+		{"unknown component", map[string]string{"GSG_COMPONENT": "db"}, nil, true},
+		{"both options", map[string]string{"GSG_COMPONENT": "api", "GSG_ALL_COMPONENTS": "true"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Resolve(newFlags(), file, env(tt.env))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			selected, err := s.SelectedComponents()
+			require.NoError(t, err)
+			names := make([]string, 0, len(selected))
+			for _, c := range selected {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	_, err = Resolve(newFlags(), nil, env(map[string]string{"GSG_ALL_COMPONENTS": "true"}))
+	assert.Error(t, err, "no components defined")
+}
+
+const versionFiles = `
+version:
+  files:
+    - path: package.json
+    - path: deploy/values.yml
+      type: regex
+      pattern: "tag: (?P<version>\\S+)"
+    - path: README.md
+      type: marker
+      marker: x-release-version
+`
+
+func TestResolveVersionFiles(t *testing.T) {
+	file, err := Parse(strings.NewReader(versionFiles))
+	require.NoError(t, err)
+	s, err := Resolve(newFlags(), file, env(nil))
+	require.NoError(t, err)
+	require.Len(t, s.VersionFiles, 3)
+	assert.Equal(t, domain.VersionFileNPM, s.VersionFiles[0].Type)
+	assert.Equal(t, domain.VersionFileRegex, s.VersionFiles[1].Type)
+	assert.Equal(t, "x-release-version", s.VersionFiles[2].Marker)
+
+	// 环境变量中的文件根据文件名推断类型，替换配置文件中的版本文件
+	s, err = Resolve(newFlags(), file, env(map[string]string{"GSG_VERSION_FILES": "Chart.yaml,cmd/version.go"}))
+	require.NoError(t, err)
+	require.Len(t, s.VersionFiles, 2)
+	assert.Equal(t, domain.VersionFileHelm, s.VersionFiles[0].Type)
+	assert.Equal(t, domain.VersionFileGo, s.VersionFiles[1].Type)
+
+	_, err = Resolve(newFlags(), file, env(map[string]string{"GSG_VERSION_FILES": "README.md"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// slugPattern 匹配分支名称中不能出现在 slug 中的字符
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Channel 是发布渠道。分支决定发布渠道，渠道决定发布正式版本还是预发布版本
+type Channel struct {
+	Name string
+	// Branches 是属于该渠道的分支，* 和 ? 匹配除 / 以外的字符，** 匹配包括 / 在内的任意字符
+	Branches []string
+	// PreRelease 是预发布版本第一个标识的模板，例如 beta 或 {{ .BranchSlug }}，为空时发布正式版本
+	PreRelease string
+}
+
+// Stable 判断渠道是否发布正式版本
+func (c Channel) Stable() bool {
+	return c.PreRelease == ""
+}
+
+// Matches 判断分支是否属于该渠道
+func (c Channel) Matches(branch string) bool {
+	return MatchBranch(c.Branches, branch)
+}
+
+// MatchBranch 判断分支是否匹配任意一个通配符，* 和 ? 匹配除 / 以外的字符，** 匹配包括 / 在内的任意字符
+func MatchBranch(patterns []string, branch string) bool {
+	for _, pattern := range patterns {
+		if branchPattern(pattern).MatchString(branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// branchPattern 把分支通配符转换为正则表达式
+func branchPattern(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// ChannelData 是渲染预发布版本和构建元数据模板时可以使用的数据
+type ChannelData struct {
+	Channel string
+	Branch  string
+	// BranchSlug 是与 GitLab 的 CI_COMMIT_REF_SLUG 相同规则生成的分支名称
+	BranchSlug string
+}
+
+// NewChannelData 返回渠道 c 在分支 branch 上渲染模板使用的数据
+func NewChannelData(c Channel, branch string) ChannelData {
+	return ChannelData{Channel: c.Name, Branch: branch, BranchSlug: BranchSlug(branch)}
+}
+
+// FindChannel 返回分支所属的第一个渠道，没有匹配的渠道时返回 false
+func FindChannel(channels []Channel, branch string) (Channel, bool) {
+	for _, c := range channels {
+		if c.Matches(branch) {
+			return c, true
+		}
+	}
+	return Channel{}, false
+}
+
+// BranchSlug 按 GitLab 生成 CI_COMMIT_REF_SLUG 的规则转换分支名称：转换为小写，
+// 字母和数字以外的字符替换为 -，最长 63 个字符，不以 - 开头或结尾
+func BranchSlug(branch string) string {
+	slug := slugPattern.ReplaceAllString(strings.ToLower(branch), "-")
+	if len(slug) > 63 {
+		slug = slug[:63]
+	}
+	return strings.Trim(slug, "-")
+}
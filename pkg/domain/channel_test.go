@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchSlug(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"main", "main"},
+		{"feature/Login_Page", "feature-login-page"},
+		{"-fix--typo-", "fix-typo"},
+		{"release/1.x", "release-1-x"},
+		{strings.Repeat("x", 70), strings.Repeat("x", 63)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, BranchSlug(tt.branch), tt.branch)
+	}
+}
+
+func TestFindChannel(t *testing.T) {
+	channels := []Channel{
+		{Name: "stable", Branches: []string{"main", "release/*"}},
+		{Name: "beta", Branches: []string{"beta"}, PreRelease: "beta"},
+		{Name: "feature", Branches: []string{"feature/**"}, PreRelease: "{{ .BranchSlug }}"},
+	}
+	tests := []struct {
+		branch string
+		want   string
+		found  bool
+	}{
+		{"main", "stable", true},
+		{"release/1.x", "stable", true},
+		{"release/1.x/hotfix", "", false},
+		{"beta", "beta", true},
+		{"feature/api/login", "feature", true},
+		{"betas", "", false},
+	}
+	for _, tt := range tests {
+		channel, ok := FindChannel(channels, tt.branch)
+		assert.Equal(t, tt.found, ok, tt.branch)
+		assert.Equal(t, tt.want, channel.Name, tt.branch)
+	}
+	assert.True(t, channels[0].Stable())
+	assert.False(t, channels[1].Stable())
+}
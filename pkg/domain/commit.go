@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+)
+
 // CommitType 表示提交类型
 type CommitType string
 
@@ -12,17 +17,28 @@ const (
 	TypeStyle    CommitType = "style"
 	TypeTest     CommitType = "test"
 	TypeChore    CommitType = "chore"
+	TypeRevert   CommitType = "revert"
 )
 
 // Commit 表示一个提交
 type Commit struct {
-	Hash       string
-	Type       CommitType
-	Scope      string
-	Subject    string
-	Body       string
-	Breaking   bool
-	PreRelease bool
+	Hash            string
+	Type            CommitType
+	Scope           string
+	Subject         string
+	Body            string
+	Footers         []Footer
+	Breaking        bool
+	BreakingMessage string
+	PreRelease      bool
+	// Header 是提交消息的标题行
+	Header string
+	// Reverts 是回滚提交正文中 "This reverts commit <sha>" 给出的被回滚提交的哈希
+	Reverts string
+	// References 是提交消息中引用的议题和合并请求
+	References []Reference
+	// Author 是提交作者的名称
+	Author string
 }
 
 // NewCommit 创建一个新的提交对象
@@ -37,7 +53,8 @@ func NewCommit(hash string, commitType CommitType, scope, subject, body string,
 	}
 }
 
-// DetermineLevel 根据提交类型和是否破坏性变更确定版本升级级别
+// DetermineLevel 根据提交类型和是否破坏性变更确定版本升级级别。
+// 回滚提交至少升级补丁版本：经过 CancelReverts 后剩下的回滚提交撤销的是已经发布的变更
 func (c *Commit) DetermineLevel(patchTypes, minorTypes []string) BumpLevel {
 	if c.Breaking {
 		return BumpMajor
@@ -55,9 +72,100 @@ func (c *Commit) DetermineLevel(patchTypes, minorTypes []string) BumpLevel {
 		}
 	}
 
+	if c.IsRevert() {
+		return BumpPatch
+	}
 	return NoBump
 }
 
+// Category 返回提交在发布说明中所属的类别
+func (c *Commit) Category() string {
+	if c.Breaking {
+		return "breaking"
+	}
+	if c.Type == "" {
+		return "other"
+	}
+	return string(c.Type)
+}
+
+// Trailers 返回标记为 token 的脚注的值，例如 Co-authored-by，标记不区分大小写
+func (c *Commit) Trailers(token string) []string {
+	values := make([]string, 0)
+	for _, f := range c.Footers {
+		if strings.EqualFold(f.Token, token) {
+			values = append(values, f.Value)
+		}
+	}
+	return values
+}
+
+// ReleaseAs 返回 Release-As 脚注指定的下一个版本，没有该脚注时返回空字符串
+func (c *Commit) ReleaseAs() string {
+	values := c.Trailers("Release-As")
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// IsRevert 判断是否为回滚提交
+func (c *Commit) IsRevert() bool {
+	return c.Type == TypeRevert
+}
+
+// IsRevertOf 判断提交是否回滚了 other。
+// 有被回滚提交的哈希时按哈希比较，否则比较回滚提交的标题和 other 的标题行。
+func (c *Commit) IsRevertOf(other *Commit) bool {
+	if !c.IsRevert() {
+		return false
+	}
+	if c.Reverts != "" {
+		return other.Hash != "" && strings.HasPrefix(strings.ToLower(other.Hash), c.Reverts)
+	}
+	return c.Subject == other.Header
+}
+
+// CancelReverts 移除相互抵消的提交：回滚提交和被它回滚的提交都在 commits 中时，两者都被移除。
+// 回滚一个已经被移除的回滚提交时，最初被回滚的提交重新生效。
+// commits 按从新到旧排列，返回的提交保持原有顺序。
+func CancelReverts(commits []*Commit) []*Commit {
+	active := make(map[*Commit]bool, len(commits))
+	// cancelled 记录被移除的回滚提交所回滚的提交
+	cancelled := make(map[*Commit]*Commit)
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		active[c] = true
+		if !c.IsRevert() {
+			continue
+		}
+		for _, target := range commits[i+1:] {
+			if !c.IsRevertOf(target) {
+				continue
+			}
+			if active[target] {
+				active[target] = false
+				active[c] = false
+				cancelled[c] = target
+				break
+			}
+			if original, ok := cancelled[target]; ok && !active[original] {
+				active[original] = true
+				active[c] = false
+				break
+			}
+		}
+	}
+
+	rv := make([]*Commit, 0, len(commits))
+	for _, c := range commits {
+		if active[c] {
+			rv = append(rv, c)
+		}
+	}
+	return rv
+}
+
 // IsPreReleased 判断是否为预发布版本的提交
 func (c *Commit) IsPreReleased() bool {
 	return c.PreRelease
@@ -67,3 +175,25 @@ func (c *Commit) IsPreReleased() bool {
 func (c *Commit) SetPreReleased(preRelease bool) {
 	c.PreRelease = preRelease
 }
+
+// MergeStrategy 表示分析合并提交的方式
+type MergeStrategy string
+
+const (
+	// MergeTitle 使用合并请求的标题作为合并提交的变更，被合并分支上的提交不再单独分析。
+	// 标题不符合 Conventional Commits 规范时退回到 MergeBranch
+	MergeTitle MergeStrategy = "title"
+	// MergeBranch 分析被合并分支上的提交，合并提交本身只有在消息符合规范时才作为变更
+	MergeBranch MergeStrategy = "branch"
+)
+
+// ParseMergeStrategy 解析合并策略的名称，空字符串表示默认的 MergeTitle
+func ParseMergeStrategy(name string) (MergeStrategy, error) {
+	switch MergeStrategy(name) {
+	case "", MergeTitle:
+		return MergeTitle, nil
+	case MergeBranch:
+		return MergeBranch, nil
+	}
+	return "", fmt.Errorf("未知的合并策略 %q，可选值为 %s 或 %s", name, MergeTitle, MergeBranch)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelReverts(t *testing.T) {
+	feat := ParseCommit("aaaaaaa111", "feat: add endpoint")
+	fix := ParseCommit("bbbbbbb222", "fix: typo")
+	revertByHash := ParseCommit("ccccccc333", "Revert \"feat: add endpoint\"\n\nThis reverts commit aaaaaaa111.")
+	revertBySubject := ParseCommit("ddddddd444", "revert: feat: add endpoint")
+	revertReleased := ParseCommit("eeeeeee555", "Revert \"feat: released\"\n\nThis reverts commit 9999999999.")
+	reapply := ParseCommit("fffffff666", "Revert \"Revert \"feat: add endpoint\"\"\n\nThis reverts commit ccccccc333.")
+
+	tests := []struct {
+		name    string
+		commits []*Commit
+		want    []*Commit
+	}{
+		{"by hash", []*Commit{revertByHash, fix, feat}, []*Commit{fix}},
+		{"by subject", []*Commit{fix, revertBySubject, feat}, []*Commit{fix}},
+		{"released commit", []*Commit{revertReleased, fix}, []*Commit{revertReleased, fix}},
+		{"revert before commit", []*Commit{feat, revertBySubject}, []*Commit{feat, revertBySubject}},
+		{"reapply", []*Commit{reapply, revertByHash, fix, feat}, []*Commit{fix, feat}},
+		{"second revert", []*Commit{revertBySubject, revertByHash, feat}, []*Commit{revertBySubject}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CancelReverts(tt.commits))
+		})
+	}
+}
+
+func TestCommitTrailers(t *testing.T) {
+	c := ParseCommit("", "feat: pair work\n\nCo-authored-by: A <a@example.com>\nco-authored-by: B <b@example.com>\nReviewed-by: C")
+	assert.Equal(t, []string{"A <a@example.com>", "B <b@example.com>"}, c.Trailers("Co-authored-by"))
+	assert.Equal(t, []string{"C"}, c.Trailers("reviewed-by"))
+	assert.Empty(t, c.Trailers("Signed-off-by"))
+}
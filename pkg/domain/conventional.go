@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// footerPattern 匹配脚注行: "Token: value"、"Token #value" 或 "BREAKING CHANGE: value"
+	footerPattern = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][\w-]*)(?:: | #)(.*)$`)
+	// revertHeaderPattern 匹配 git revert 生成的标题行: Revert "subject"
+	revertHeaderPattern = regexp.MustCompile(`^Revert "(.*)"$`)
+	// revertBodyPattern 匹配 git revert 生成的正文: This reverts commit <sha>
+	revertBodyPattern = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-fA-F]{7,40})\b`)
+)
+
+// Footer 表示提交消息中的一个脚注
+type Footer struct {
+	Token string
+	Value string
+}
+
+// IsBreaking 判断脚注是否为破坏性变更说明
+func (f Footer) IsBreaking() bool {
+	return f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE"
+}
+
+// ParseCommit 按照 Conventional Commits 1.0 规范解析提交消息。
+// 不符合规范的消息会得到一个类型为空、标题为首行的提交对象。
+// git revert 生成的 Revert "subject" 和 revert: subject 都被解析为 revert 类型，
+// 标题为被回滚提交的标题行。
+// 标题、正文和脚注中引用的议题和合并请求被提取到 References。
+func ParseCommit(hash, message string) *Commit {
+	return conventionalParser.Parse(hash, message)
+}
+
+// headerParser 解析提交消息的标题行，设置提交的类型、范围、标题和破坏性变更标记。
+// 标题行不符合约定时返回 false。
+type headerParser func(c *Commit, header string) bool
+
+// parseCommit 用 parseHeader 解析标题行，正文、脚注、回滚提交和引用的解析对所有约定都相同
+func parseCommit(hash, message string, parseHeader headerParser) *Commit {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(message), "\r\n", "\n"), "\n")
+	header := strings.TrimSpace(lines[0])
+
+	c := &Commit{
+		Hash:    hash,
+		Header:  header,
+		Subject: header,
+	}
+
+	if !parseHeader(c, header) {
+		if m := revertHeaderPattern.FindStringSubmatch(header); m != nil {
+			c.Type = TypeRevert
+			c.Subject = m[1]
+		}
+	}
+	if c.IsRevert() {
+		if m := revertBodyPattern.FindStringSubmatch(strings.Join(lines[1:], "\n")); m != nil {
+			c.Reverts = strings.ToLower(m[1])
+		}
+	}
+
+	body, footers := splitBodyAndFooters(lines[1:])
+	c.Body = body
+	c.Footers = footers
+
+	for _, f := range footers {
+		if f.IsBreaking() {
+			c.Breaking = true
+			if c.BreakingMessage == "" {
+				c.BreakingMessage = f.Value
+			}
+		}
+	}
+	if c.Breaking && c.BreakingMessage == "" {
+		c.BreakingMessage = c.Subject
+	}
+	c.References = parseReferences(c)
+
+	return c
+}
+
+// splitBodyAndFooters 拆分正文和脚注。
+// 脚注是消息末尾连续的、以脚注标记开头的段落。
+func splitBodyAndFooters(lines []string) (string, []Footer) {
+	paragraphs := make([][]string, 0)
+	current := make([]string, 0)
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, current)
+				current = make([]string, 0)
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, current)
+	}
+
+	start := len(paragraphs)
+	for start > 0 && footerPattern.MatchString(paragraphs[start-1][0]) {
+		start--
+	}
+
+	bodyParts := make([]string, 0, start)
+	for _, p := range paragraphs[:start] {
+		bodyParts = append(bodyParts, strings.Join(p, "\n"))
+	}
+
+	footers := make([]Footer, 0)
+	for _, p := range paragraphs[start:] {
+		for _, line := range p {
+			if m := footerPattern.FindStringSubmatch(line); m != nil {
+				footers = append(footers, Footer{Token: m[1], Value: strings.TrimSpace(m[2])})
+				continue
+			}
+			// 不以标记开头的行属于上一个脚注的值
+			footers[len(footers)-1].Value += "\n" + line
+		}
+	}
+
+	return strings.Join(bodyParts, "\n\n"), footers
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		name            string
+		message         string
+		wantType        CommitType
+		wantScope       string
+		wantSubject     string
+		wantBody        string
+		wantFooters     []Footer
+		wantBreaking    bool
+		wantBreakingMsg string
+	}{
+		{
+			name:        "simple feat",
+			message:     "feat: add login",
+			wantType:    TypeFeat,
+			wantSubject: "add login",
+			wantFooters: []Footer{},
+		},
+		{
+			name:        "scope",
+			message:     "fix(api): handle nil response",
+			wantType:    TypeFix,
+			wantScope:   "api",
+			wantSubject: "handle nil response",
+			wantFooters: []Footer{},
+		},
+		{
+			name:            "breaking marker with scope",
+			message:         "fix(api)!: drop v3 endpoints",
+			wantType:        TypeFix,
+			wantScope:       "api",
+			wantSubject:     "drop v3 endpoints",
+			wantFooters:     []Footer{},
+			wantBreaking:    true,
+			wantBreakingMsg: "drop v3 endpoints",
+		},
+		{
+			name:        "type is case insensitive",
+			message:     "Feat: upper case type",
+			wantType:    TypeFeat,
+			wantSubject: "upper case type",
+			wantFooters: []Footer{},
+		},
+		{
+			name:        "not conventional",
+			message:     "Merge branch 'x' into 'main'\n\nSee merge request group/proj!1",
+			wantSubject: "Merge branch 'x' into 'main'",
+			wantBody:    "See merge request group/proj!1",
+			wantFooters: []Footer{},
+		},
+		{
+			name:        "body and footers",
+			message:     "feat(auth): use JWT\n\nfirst paragraph\nstill first\n\nsecond paragraph\n\nReviewed-by: Z\nRefs #133",
+			wantType:    TypeFeat,
+			wantScope:   "auth",
+			wantSubject: "use JWT",
+			wantBody:    "first paragraph\nstill first\n\nsecond paragraph",
+			wantFooters: []Footer{{Token: "Reviewed-by", Value: "Z"}, {Token: "Refs", Value: "133"}},
+		},
+		{
+			name:            "breaking change footer",
+			message:         "refactor: switch session storage\n\nBREAKING CHANGE: sessions are stored in redis\nold sessions are dropped",
+			wantType:        TypeRefactor,
+			wantSubject:     "switch session storage",
+			wantFooters:     []Footer{{Token: "BREAKING CHANGE", Value: "sessions are stored in redis\nold sessions are dropped"}},
+			wantBreaking:    true,
+			wantBreakingMsg: "sessions are stored in redis\nold sessions are dropped",
+		},
+		{
+			name:            "breaking change synonym",
+			message:         "feat!: new config format\n\nBREAKING-CHANGE: config v1 is not read anymore",
+			wantType:        TypeFeat,
+			wantSubject:     "new config format",
+			wantFooters:     []Footer{{Token: "BREAKING-CHANGE", Value: "config v1 is not read anymore"}},
+			wantBreaking:    true,
+			wantBreakingMsg: "config v1 is not read anymore",
+		},
+		{
+			name:        "windows line endings",
+			message:     "docs: readme\r\n\r\nAcked-by: X\r\n",
+			wantType:    TypeDocs,
+			wantSubject: "readme",
+			wantFooters: []Footer{{Token: "Acked-by", Value: "X"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ParseCommit("abcdef1234567", tt.message)
+			assert.Equal(t, "abcdef1234567", c.Hash)
+			assert.Equal(t, tt.wantType, c.Type)
+			assert.Equal(t, tt.wantScope, c.Scope)
+			assert.Equal(t, tt.wantSubject, c.Subject)
+			assert.Equal(t, tt.wantBody, c.Body)
+			assert.Equal(t, tt.wantFooters, c.Footers)
+			assert.Equal(t, tt.wantBreaking, c.Breaking)
+			assert.Equal(t, tt.wantBreakingMsg, c.BreakingMessage)
+		})
+	}
+}
+
+func TestParseCommitDetermineLevel(t *testing.T) {
+	patch := []string{"fix", "refactor"}
+	minor := []string{"feat"}
+
+	tests := []struct {
+		message string
+		want    BumpLevel
+	}{
+		{"feat: a", BumpMinor},
+		{"fix(x): a", BumpPatch},
+		{"fix(x)!: a", BumpMajor},
+		{"chore: a", NoBump},
+		{"chore: a\n\nBREAKING CHANGE: b", BumpMajor},
+		{"some message", NoBump},
+		{"Revert \"feat: a\"\n\nThis reverts commit 1234567.", BumpPatch},
+		{"revert: feat: a", BumpPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseCommit("", tt.message).DetermineLevel(patch, minor))
+		})
+	}
+}
+
+func TestParseCommitRevert(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		wantSubject string
+		wantReverts string
+	}{
+		{"git revert", "Revert \"feat(api): add endpoint\"\n\nThis reverts commit 0123456789ABCDEF0123456789abcdef01234567.", "feat(api): add endpoint", "0123456789abcdef0123456789abcdef01234567"},
+		{"conventional revert", "revert: feat: add endpoint\n\nThis reverts commit 0123456.", "feat: add endpoint", "0123456"},
+		{"without hash", "revert: feat: add endpoint", "feat: add endpoint", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ParseCommit("", tt.message)
+			assert.Equal(t, TypeRevert, c.Type)
+			assert.True(t, c.IsRevert())
+			assert.Equal(t, tt.wantSubject, c.Subject)
+			assert.Equal(t, tt.wantReverts, c.Reverts)
+			assert.Equal(t, "revert", c.Category())
+		})
+	}
+}
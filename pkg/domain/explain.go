@@ -0,0 +1,131 @@
+package domain
+
+import "fmt"
+
+// IgnoreReason 是提交没有参与版本计算的原因
+type IgnoreReason string
+
+const (
+	// IgnoreCoveredByMerge 表示提交被合并请求标题代表的合并提交代替
+	IgnoreCoveredByMerge IgnoreReason = "covered_by_merge"
+	// IgnoreOutsidePaths 表示提交没有修改组件的路径
+	IgnoreOutsidePaths IgnoreReason = "outside_paths"
+	// IgnoreEmptyMessage 表示提交消息为空
+	IgnoreEmptyMessage IgnoreReason = "empty_message"
+	// IgnoreUnrecognizedMerge 表示合并提交的消息不符合提交约定，变更来自被合并的提交
+	IgnoreUnrecognizedMerge IgnoreReason = "unrecognized_merge"
+	// IgnoreReverted 表示提交和回滚它的提交相互抵消
+	IgnoreReverted IgnoreReason = "reverted"
+	// IgnoreType 表示提交类型在 commit.ignore_types 中，并且不是破坏性变更
+	IgnoreType IgnoreReason = "ignored_type"
+)
+
+// Description 返回原因的说明
+func (r IgnoreReason) Description() string {
+	switch r {
+	case IgnoreCoveredByMerge:
+		return "已由合并请求标题代表"
+	case IgnoreOutsidePaths:
+		return "没有修改组件的路径"
+	case IgnoreEmptyMessage:
+		return "提交消息为空"
+	case IgnoreUnrecognizedMerge:
+		return "不符合提交约定的合并提交"
+	case IgnoreReverted:
+		return "与回滚提交相互抵消"
+	case IgnoreType:
+		return "提交类型被忽略"
+	default:
+		return string(r)
+	}
+}
+
+// Explanation 说明下一个版本是怎样确定的
+type Explanation struct {
+	// BaseTag 是确定当前版本的发布标签，没有发布标签时为空
+	BaseTag    string `json:"base_tag,omitempty"`
+	BaseCommit string `json:"base_commit,omitempty"`
+	// Commits 是参与版本计算的提交，按从新到旧排列
+	Commits []ExplainedCommit `json:"commits"`
+	// Ignored 是没有参与版本计算的未发布提交
+	Ignored  []IgnoredCommit `json:"ignored"`
+	Decision Decision        `json:"decision"`
+}
+
+// ExplainedCommit 是参与版本计算的提交及其解析结果
+type ExplainedCommit struct {
+	Hash     string    `json:"hash"`
+	Header   string    `json:"header"`
+	Type     string    `json:"type"`
+	Scope    string    `json:"scope,omitempty"`
+	Breaking bool      `json:"breaking"`
+	Level    BumpLevel `json:"level"`
+	// ReleaseAs 是提交中 Release-As 脚注的值
+	ReleaseAs string `json:"release_as,omitempty"`
+}
+
+// IgnoredCommit 是没有参与版本计算的提交
+type IgnoredCommit struct {
+	Hash   string       `json:"hash"`
+	Header string       `json:"header"`
+	Reason IgnoreReason `json:"reason"`
+}
+
+// Decision 是根据提交和版本计算策略确定的下一个版本
+type Decision struct {
+	Current            string    `json:"current"`
+	CommitLevel        BumpLevel `json:"commit_level"`
+	InitialDevelopment bool      `json:"initial_development"`
+	BumpPatch          bool      `json:"bump_patch"`
+	ReleaseAs          string    `json:"release_as,omitempty"`
+	ReleaseAsSource    string    `json:"release_as_source,omitempty"`
+	// Channel 是根据分支选择的发布渠道
+	Channel string `json:"channel,omitempty"`
+	// Maintenance 是维护分支允许的版本范围，例如 1.x
+	Maintenance string `json:"maintenance,omitempty"`
+	// Adjustments 说明版本计算策略或指定版本对 CommitLevel 的调整
+	Adjustments []string  `json:"adjustments"`
+	Level       BumpLevel `json:"level"`
+	Next        string    `json:"next"`
+}
+
+// Explain 返回提交 c 参与版本计算的说明
+func (c *Commit) Explain(patchTypes, minorTypes []string) ExplainedCommit {
+	return ExplainedCommit{
+		Hash:      c.Hash,
+		Header:    c.Header,
+		Type:      string(c.Type),
+		Scope:     c.Scope,
+		Breaking:  c.Breaking,
+		Level:     c.DetermineLevel(patchTypes, minorTypes),
+		ReleaseAs: c.ReleaseAs(),
+	}
+}
+
+// Decide 根据版本 v 的计算结果设置 Decision
+func (e *Explanation) Decide(v *Version) {
+	d := Decision{
+		Current:            v.CurrentString(),
+		CommitLevel:        v.CommitLevel,
+		InitialDevelopment: v.Options.InitialDevelopment,
+		BumpPatch:          v.Options.BumpPatch,
+		Adjustments:        make([]string, 0),
+		Channel:            v.Channel,
+		Level:              v.Level,
+		Next:               v.NextString(),
+	}
+	switch {
+	case v.ReleaseAs != nil:
+		d.ReleaseAs = v.Format(*v.ReleaseAs)
+		d.ReleaseAsSource = v.ReleaseAsSource
+		d.Adjustments = append(d.Adjustments, fmt.Sprintf("由 %s 指定下一个版本 %s", v.ReleaseAsSource, d.ReleaseAs))
+	case v.CommitLevel == NoBump && v.Level == BumpPatch:
+		d.Adjustments = append(d.Adjustments, "没有提交触发升级，bump-patch 强制升级补丁版本")
+	case v.CommitLevel == BumpMajor && v.Level == BumpMinor:
+		d.Adjustments = append(d.Adjustments, "当前版本低于 1.0.0 且处于初始开发阶段，破坏性变更只升级次版本号")
+	}
+	if v.Channel != "" && len(v.Next.Pre) > 0 && (v.ReleaseAs == nil || len(v.ReleaseAs.Pre) == 0) {
+		d.Adjustments = append(d.Adjustments, fmt.Sprintf("发布渠道 %s 发布预发布版本", v.Channel))
+	}
+	e.Decision = d
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// maintenancePattern 匹配维护分支名称中的版本范围，例如 1.x 或 1.2.x
+var maintenancePattern = regexp.MustCompile(`^v?(\d+)\.(?:(\d+)\.)?x$`)
+
+// MaintenanceRange 是维护分支允许发布的版本范围。1.x 允许 1.*.*，1.2.x 允许 1.2.*
+type MaintenanceRange struct {
+	Major uint64
+	Minor uint64
+	// MinorFixed 为 true 时次版本号固定，只能发布补丁版本
+	MinorFixed bool
+}
+
+// ParseMaintenanceBranch 从维护分支名称的最后一段解析版本范围，例如 1.x、v1.2.x 或 release/1.x
+func ParseMaintenanceBranch(branch string) (MaintenanceRange, error) {
+	m := maintenancePattern.FindStringSubmatch(path.Base(branch))
+	if m == nil {
+		return MaintenanceRange{}, fmt.Errorf("维护分支 %s 的名称必须以 <major>.x 或 <major>.<minor>.x 结尾", branch)
+	}
+	r := MaintenanceRange{}
+	var err error
+	if r.Major, err = strconv.ParseUint(m[1], 10, 64); err != nil {
+		return MaintenanceRange{}, fmt.Errorf("维护分支 %s 的主版本号无效: %v", branch, err)
+	}
+	if m[2] != "" {
+		if r.Minor, err = strconv.ParseUint(m[2], 10, 64); err != nil {
+			return MaintenanceRange{}, fmt.Errorf("维护分支 %s 的次版本号无效: %v", branch, err)
+		}
+		r.MinorFixed = true
+	}
+	return r, nil
+}
+
+// Contains 判断版本 v 是否在范围内
+func (r MaintenanceRange) Contains(v semver.Version) bool {
+	return v.Major == r.Major && (!r.MinorFixed || v.Minor == r.Minor)
+}
+
+// MaxLevel 返回范围内允许的最高升级级别
+func (r MaintenanceRange) MaxLevel() BumpLevel {
+	if r.MinorFixed {
+		return BumpPatch
+	}
+	return BumpMinor
+}
+
+// String 返回范围的分支形式，例如 1.x 或 1.2.x
+func (r MaintenanceRange) String() string {
+	if r.MinorFixed {
+		return fmt.Sprintf("%d.%d.x", r.Major, r.Minor)
+	}
+	return fmt.Sprintf("%d.x", r.Major)
+}
+
+// Bounds 返回范围的上下界，例如 >=1.2.0 <1.3.0
+func (r MaintenanceRange) Bounds() string {
+	if r.MinorFixed {
+		return fmt.Sprintf(">=%d.%d.0 <%d.%d.0", r.Major, r.Minor, r.Major, r.Minor+1)
+	}
+	return fmt.Sprintf(">=%d.0.0 <%d.0.0", r.Major, r.Major+1)
+}
+
+// Check 检查下一个版本是否在范围内，超出范围时返回的错误列出导致超出范围的提交
+func (r MaintenanceRange) Check(v *Version, changes []*Commit, patchTypes, minorTypes []string) error {
+	if r.Contains(v.Next) {
+		return nil
+	}
+	prefix := fmt.Sprintf("下一个版本 %s 超出维护分支 %s 的范围 %s", v.NextString(), r, r.Bounds())
+	if v.ReleaseAs != nil {
+		return fmt.Errorf("%s: 版本由 %s 指定", prefix, v.ReleaseAsSource)
+	}
+	offending := make([]string, 0)
+	for _, c := range changes {
+		if c.DetermineLevel(patchTypes, minorTypes) > r.MaxLevel() {
+			offending = append(offending, fmt.Sprintf("%.7s %s", c.Hash, c.Header))
+		}
+	}
+	if len(offending) == 0 {
+		return fmt.Errorf("%s", prefix)
+	}
+	return fmt.Errorf("%s，最高只允许 %s 升级，以下提交需要更高的升级级别: %s", prefix, r.MaxLevel(), strings.Join(offending, "; "))
+}
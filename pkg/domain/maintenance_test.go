@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMaintenanceBranch(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+		bounds string
+		err    bool
+	}{
+		{branch: "1.x", want: "1.x", bounds: ">=1.0.0 <2.0.0"},
+		{branch: "v1.2.x", want: "1.2.x", bounds: ">=1.2.0 <1.3.0"},
+		{branch: "release/0.x", want: "0.x", bounds: ">=0.0.0 <1.0.0"},
+		{branch: "1.2.3.x", err: true},
+		{branch: "hotfix.x", err: true},
+		{branch: "1.x/fix", err: true},
+	}
+	for _, tt := range tests {
+		r, err := ParseMaintenanceBranch(tt.branch)
+		if tt.err {
+			assert.Error(t, err, tt.branch)
+			continue
+		}
+		require.NoError(t, err, tt.branch)
+		assert.Equal(t, tt.want, r.String())
+		assert.Equal(t, tt.bounds, r.Bounds())
+	}
+}
+
+func TestMaintenanceRangeContains(t *testing.T) {
+	major := MaintenanceRange{Major: 1}
+	minor := MaintenanceRange{Major: 1, Minor: 2, MinorFixed: true}
+	tests := []struct {
+		version string
+		major   bool
+		minor   bool
+	}{
+		{"1.0.0", true, false},
+		{"1.2.9", true, true},
+		{"1.3.0", true, false},
+		{"2.0.0", false, false},
+	}
+	for _, tt := range tests {
+		v := semver.MustParse(tt.version)
+		assert.Equal(t, tt.major, major.Contains(v), tt.version)
+		assert.Equal(t, tt.minor, minor.Contains(v), tt.version)
+	}
+	assert.Equal(t, BumpMinor, major.MaxLevel())
+	assert.Equal(t, BumpPatch, minor.MaxLevel())
+}
+
+func TestMaintenanceRangeCheck(t *testing.T) {
+	r := MaintenanceRange{Major: 1, Minor: 2, MinorFixed: true}
+	fix := ParseCommit("1111111aaa", "fix: patch")
+	feat := ParseCommit("2222222bbb", "feat: backport")
+
+	v := NewVersion(time.Now())
+	v.SetCurrent(semver.MustParse("1.2.3"))
+	v.Bump(fix.DetermineLevel([]string{"fix"}, []string{"feat"}))
+	assert.NoError(t, r.Check(v, []*Commit{fix}, []string{"fix"}, []string{"feat"}))
+
+	v.Bump(feat.DetermineLevel([]string{"fix"}, []string{"feat"}))
+	err := r.Check(v, []*Commit{fix, feat}, []string{"fix"}, []string{"feat"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1.3.0")
+	assert.Contains(t, err.Error(), "2222222 feat: backport")
+	assert.NotContains(t, err.Error(), "1111111")
+}
@@ -0,0 +1,305 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CommitParser 把提交消息解析为提交对象。
+// 不同的提交约定由不同的解析器处理，解析结果使用相同的提交类型，
+// 因此版本计算和发布说明与团队使用的约定无关。
+type CommitParser interface {
+	Parse(hash, message string) *Commit
+}
+
+// CommitParserFunc 把函数适配为 CommitParser，例如 CommitParserFunc(ParseCommit)
+type CommitParserFunc func(hash, message string) *Commit
+
+// Parse 调用 f(hash, message)
+func (f CommitParserFunc) Parse(hash, message string) *Commit {
+	return f(hash, message)
+}
+
+// Convention 是内置的提交约定
+type Convention string
+
+const (
+	// ConventionConventional 是 Conventional Commits 1.0: feat(api)!: subject
+	ConventionConventional Convention = "conventional"
+	// ConventionAngular 是 Angular 提交规范，只接受 Angular 定义的类型，破坏性变更只能通过脚注说明
+	ConventionAngular Convention = "angular"
+	// ConventionGitmoji 是 Gitmoji: :sparkles: subject 或 ✨ subject
+	ConventionGitmoji Convention = "gitmoji"
+	// ConventionBracket 是以方括号标记类型的约定: [FEATURE] subject
+	ConventionBracket Convention = "bracket"
+	// ConventionJira 是以 Jira 议题编号开头的 Conventional Commits: PROJ-123 feat: subject
+	ConventionJira Convention = "jira"
+	// ConventionRegex 使用配置的正则表达式解析标题行
+	ConventionRegex Convention = "regex"
+)
+
+// Conventions 是所有可用的提交约定
+var Conventions = []Convention{
+	ConventionConventional, ConventionAngular, ConventionGitmoji,
+	ConventionBracket, ConventionJira, ConventionRegex,
+}
+
+var (
+	// conventionalPattern 匹配 Conventional Commits 的标题行: type(scope)!: subject
+	conventionalPattern = `^(?P<type>[A-Za-z][\w-]*)(?:\((?P<scope>[^()\r\n]*)\))?(?P<breaking>!)?: +(?P<subject>\S.*)$`
+	// angularPattern 匹配 Angular 规范的标题行: type(scope): subject
+	angularPattern = `^(?P<type>build|ci|docs|feat|fix|perf|refactor|style|test|chore|revert)(?:\((?P<scope>[^()\r\n]*)\))?: (?P<subject>\S.*)$`
+	// gitmojiPattern 匹配 Gitmoji 的标题行: :code: (scope)!: subject，冒号和范围都可以省略
+	gitmojiPattern = `^:(?P<type>[\w+-]+):\s*(?:\((?P<scope>[^()\r\n]*)\))?(?P<breaking>!)?:?\s*(?P<subject>\S.*)$`
+	// bracketPattern 匹配以方括号标记类型的标题行: [TYPE] (scope)!: subject
+	bracketPattern = `^\[(?P<type>[A-Za-z][\w-]*)\]\s*(?:\((?P<scope>[^()\r\n]*)\))?(?P<breaking>!)?:?\s*(?P<subject>\S.*)$`
+	// jiraPrefixPattern 匹配标题行开头的 Jira 议题编号: PROJ-123、[PROJ-123] 或 PROJ-123:
+	jiraPrefixPattern = regexp.MustCompile(`^\[?([A-Z][A-Z0-9_]+-\d+)\]?:?\s+(\S.*)$`)
+)
+
+// gitmojiTypes 是 Gitmoji 代码对应的提交类型，以 ! 结尾的类型表示破坏性变更
+var gitmojiTypes = map[string]string{
+	"sparkles":            "feat",
+	"tada":                "feat",
+	"bug":                 "fix",
+	"ambulance":           "fix",
+	"lock":                "fix",
+	"adhesive_bandage":    "fix",
+	"boom":                "feat!",
+	"recycle":             "refactor",
+	"truck":               "refactor",
+	"zap":                 "perf",
+	"memo":                "docs",
+	"pencil2":             "docs",
+	"art":                 "style",
+	"lipstick":            "style",
+	"white_check_mark":    "test",
+	"wrench":              "chore",
+	"hammer":              "chore",
+	"fire":                "chore",
+	"arrow_up":            "chore",
+	"arrow_down":          "chore",
+	"heavy_plus_sign":     "chore",
+	"heavy_minus_sign":    "chore",
+	"green_heart":         "ci",
+	"construction_worker": "ci",
+	"package":             "build",
+	"rewind":              "revert",
+}
+
+// gitmojiCodes 是 Unicode 表情对应的 Gitmoji 代码
+var gitmojiCodes = map[string]string{
+	"✨": "sparkles", "🎉": "tada", "🐛": "bug", "🚑": "ambulance", "🔒": "lock",
+	"🩹": "adhesive_bandage", "💥": "boom", "♻": "recycle", "🚚": "truck", "⚡": "zap",
+	"📝": "memo", "✏": "pencil2", "🎨": "art", "💄": "lipstick", "✅": "white_check_mark",
+	"🔧": "wrench", "🔨": "hammer", "🔥": "fire", "⬆": "arrow_up", "⬇": "arrow_down",
+	"➕": "heavy_plus_sign", "➖": "heavy_minus_sign", "💚": "green_heart",
+	"👷": "construction_worker", "📦": "package", "⏪": "rewind",
+}
+
+// bracketTypes 是方括号中的标记对应的提交类型
+var bracketTypes = map[string]string{
+	"feature":     "feat",
+	"feat":        "feat",
+	"new":         "feat",
+	"add":         "feat",
+	"fix":         "fix",
+	"bugfix":      "fix",
+	"bug":         "fix",
+	"hotfix":      "fix",
+	"breaking":    "feat!",
+	"major":       "feat!",
+	"refactor":    "refactor",
+	"perf":        "perf",
+	"performance": "perf",
+	"doc":         "docs",
+	"docs":        "docs",
+	"style":       "style",
+	"test":        "test",
+	"tests":       "test",
+	"chore":       "chore",
+	"build":       "build",
+	"ci":          "ci",
+	"revert":      "revert",
+}
+
+// conventionalParser 是 ParseCommit 使用的解析器
+var conventionalParser = mustRegexParser(conventionalPattern, nil, false)
+
+// NewCommitParser 创建 convention 约定的解析器，空字符串表示 Conventional Commits。
+// pattern 是 regex 约定使用的正则表达式，其他约定不能设置。
+// types 把解析出的类型（不区分大小写）映射为版本计算使用的类型，
+// 以 ! 结尾的类型表示破坏性变更，覆盖约定内置的映射。
+func NewCommitParser(convention, pattern string, types map[string]string) (CommitParser, error) {
+	if pattern != "" && Convention(convention) != ConventionRegex {
+		return nil, fmt.Errorf("只有 %s 约定可以设置正则表达式", ConventionRegex)
+	}
+	for name, t := range types {
+		if strings.TrimSpace(name) == "" || strings.TrimSuffix(strings.TrimSpace(t), "!") == "" {
+			return nil, fmt.Errorf("类型映射 %q: %q 不能为空", name, t)
+		}
+	}
+
+	switch Convention(convention) {
+	case "", ConventionConventional:
+		return NewRegexParser(conventionalPattern, types)
+	case ConventionAngular:
+		return NewRegexParser(angularPattern, types)
+	case ConventionGitmoji:
+		p, err := newRegexParser(gitmojiPattern, mergeTypes(gitmojiTypes, types), true)
+		if err != nil {
+			return nil, err
+		}
+		p.normalize = gitmojiCode
+		return p, nil
+	case ConventionBracket:
+		return newRegexParser(bracketPattern, mergeTypes(bracketTypes, types), true)
+	case ConventionJira:
+		p, err := NewRegexParser(conventionalPattern, types)
+		if err != nil {
+			return nil, err
+		}
+		return &JiraParser{parser: p}, nil
+	case ConventionRegex:
+		if pattern == "" {
+			return nil, fmt.Errorf("%s 约定需要设置正则表达式", ConventionRegex)
+		}
+		return NewRegexParser(pattern, types)
+	}
+	return nil, fmt.Errorf("未知的提交约定 %q，可选值为 %s", convention, joinConventions())
+}
+
+// RegexParser 用正则表达式解析标题行。
+// 命名分组 type 和 subject 是提交类型和标题，scope 是范围，breaking 匹配到内容时表示破坏性变更。
+type RegexParser struct {
+	pattern *regexp.Regexp
+	// types 的键是小写的类型
+	types map[string]string
+	// strict 为 true 时只接受 types 中的类型
+	strict bool
+	// normalize 在匹配前转换标题行，为 nil 时不转换
+	normalize func(header string) string
+}
+
+// NewRegexParser 创建用 pattern 解析标题行的解析器，types 把解析出的类型映射为版本计算使用的类型。
+// 没有映射的类型转换为小写后使用。
+func NewRegexParser(pattern string, types map[string]string) (*RegexParser, error) {
+	return newRegexParser(pattern, types, false)
+}
+
+func newRegexParser(pattern string, types map[string]string, strict bool) (*RegexParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("正则表达式无效: %v", err)
+	}
+	for _, group := range []string{"type", "subject"} {
+		if re.SubexpIndex(group) < 0 {
+			return nil, fmt.Errorf("正则表达式缺少命名分组 (?P<%s>...)", group)
+		}
+	}
+	lower := make(map[string]string, len(types))
+	for name, t := range types {
+		lower[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(t)
+	}
+	return &RegexParser{pattern: re, types: lower, strict: strict}, nil
+}
+
+func mustRegexParser(pattern string, types map[string]string, strict bool) *RegexParser {
+	p, err := newRegexParser(pattern, types, strict)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Parse 解析提交消息，标题行不匹配时得到一个类型为空、标题为首行的提交对象
+func (p *RegexParser) Parse(hash, message string) *Commit {
+	return parseCommit(hash, message, p.parseHeader)
+}
+
+func (p *RegexParser) parseHeader(c *Commit, header string) bool {
+	if p.normalize != nil {
+		header = p.normalize(header)
+	}
+	m := p.pattern.FindStringSubmatch(header)
+	if m == nil {
+		return false
+	}
+	group := func(name string) string {
+		if i := p.pattern.SubexpIndex(name); i >= 0 {
+			return strings.TrimSpace(m[i])
+		}
+		return ""
+	}
+
+	commitType := strings.ToLower(group("type"))
+	breaking := group("breaking") != ""
+	if t, ok := p.types[commitType]; ok {
+		commitType = strings.ToLower(strings.TrimSuffix(t, "!"))
+		breaking = breaking || strings.HasSuffix(t, "!")
+	} else if p.strict {
+		return false
+	}
+	subject := group("subject")
+	if commitType == "" || subject == "" {
+		return false
+	}
+
+	c.Type = CommitType(commitType)
+	c.Scope = group("scope")
+	c.Breaking = breaking
+	c.Subject = subject
+	return true
+}
+
+// JiraParser 解析以 Jira 议题编号开头的提交消息。
+// 议题编号作为外部引用，剩余的标题行按 Conventional Commits 解析。
+type JiraParser struct {
+	parser *RegexParser
+}
+
+// Parse 解析提交消息，没有议题编号的消息按 Conventional Commits 解析
+func (p *JiraParser) Parse(hash, message string) *Commit {
+	return parseCommit(hash, message, p.parseHeader)
+}
+
+func (p *JiraParser) parseHeader(c *Commit, header string) bool {
+	m := jiraPrefixPattern.FindStringSubmatch(header)
+	if m == nil {
+		return p.parser.parseHeader(c, header)
+	}
+	c.Subject = m[2]
+	c.References = append(c.References, Reference{Kind: ReferenceExternal, ID: m[1]})
+	return p.parser.parseHeader(c, m[2])
+}
+
+// gitmojiCode 把标题行开头的 Unicode 表情替换为 :code: 形式
+func gitmojiCode(header string) string {
+	for emoji, code := range gitmojiCodes {
+		if rest, ok := strings.CutPrefix(header, emoji); ok {
+			return ":" + code + ":" + strings.TrimPrefix(rest, "\uFE0F")
+		}
+	}
+	return header
+}
+
+// mergeTypes 返回 defaults 和 overrides 合并后的类型映射，overrides 中的键不区分大小写
+func mergeTypes(defaults, overrides map[string]string) map[string]string {
+	rv := make(map[string]string, len(defaults)+len(overrides))
+	for name, t := range defaults {
+		rv[name] = t
+	}
+	for name, t := range overrides {
+		rv[strings.ToLower(name)] = t
+	}
+	return rv
+}
+
+func joinConventions() string {
+	names := make([]string, 0, len(Conventions))
+	for _, c := range Conventions {
+		names = append(names, string(c))
+	}
+	return strings.Join(names, ", ")
+}
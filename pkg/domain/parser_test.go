@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitParsers(t *testing.T) {
+	tests := []struct {
+		name         string
+		convention   Convention
+		pattern      string
+		types        map[string]string
+		message      string
+		wantType     CommitType
+		wantScope    string
+		wantSubject  string
+		wantBreaking bool
+	}{
+		{
+			name:        "conventional",
+			convention:  ConventionConventional,
+			message:     "feat(api): add login",
+			wantType:    TypeFeat,
+			wantScope:   "api",
+			wantSubject: "add login",
+		},
+		{
+			name:        "conventional with type map",
+			convention:  ConventionConventional,
+			types:       map[string]string{"Feature": "feat"},
+			message:     "feature: add login",
+			wantType:    TypeFeat,
+			wantSubject: "add login",
+		},
+		{
+			name:        "angular",
+			convention:  ConventionAngular,
+			message:     "fix(core): handle nil",
+			wantType:    TypeFix,
+			wantScope:   "core",
+			wantSubject: "handle nil",
+		},
+		{
+			name:        "angular rejects unknown type",
+			convention:  ConventionAngular,
+			message:     "feature: add login",
+			wantSubject: "feature: add login",
+		},
+		{
+			name:        "angular rejects breaking marker",
+			convention:  ConventionAngular,
+			message:     "feat!: drop v1",
+			wantSubject: "feat!: drop v1",
+		},
+		{
+			name:         "angular breaking footer",
+			convention:   ConventionAngular,
+			message:      "feat: drop v1\n\nBREAKING CHANGE: v1 is gone",
+			wantType:     TypeFeat,
+			wantSubject:  "drop v1",
+			wantBreaking: true,
+		},
+		{
+			name:        "gitmoji code",
+			convention:  ConventionGitmoji,
+			message:     ":sparkles: add login",
+			wantType:    TypeFeat,
+			wantSubject: "add login",
+		},
+		{
+			name:        "gitmoji unicode with scope",
+			convention:  ConventionGitmoji,
+			message:     "🐛 (api): handle nil",
+			wantType:    TypeFix,
+			wantScope:   "api",
+			wantSubject: "handle nil",
+		},
+		{
+			name:        "gitmoji variation selector",
+			convention:  ConventionGitmoji,
+			message:     "♻️ simplify parser",
+			wantType:    TypeRefactor,
+			wantSubject: "simplify parser",
+		},
+		{
+			name:         "gitmoji breaking",
+			convention:   ConventionGitmoji,
+			message:      ":boom: drop v1",
+			wantType:     TypeFeat,
+			wantSubject:  "drop v1",
+			wantBreaking: true,
+		},
+		{
+			name:        "gitmoji unknown code",
+			convention:  ConventionGitmoji,
+			message:     ":rocket: deploy",
+			wantSubject: ":rocket: deploy",
+		},
+		{
+			name:        "gitmoji custom code",
+			convention:  ConventionGitmoji,
+			types:       map[string]string{"rocket": "chore"},
+			message:     ":rocket: deploy",
+			wantType:    TypeChore,
+			wantSubject: "deploy",
+		},
+		{
+			name:        "bracket",
+			convention:  ConventionBracket,
+			message:     "[FEATURE] add login",
+			wantType:    TypeFeat,
+			wantSubject: "add login",
+		},
+		{
+			name:        "bracket with scope",
+			convention:  ConventionBracket,
+			message:     "[BugFix](api): handle nil",
+			wantType:    TypeFix,
+			wantScope:   "api",
+			wantSubject: "handle nil",
+		},
+		{
+			name:         "bracket breaking",
+			convention:   ConventionBracket,
+			message:      "[BREAKING] drop v1",
+			wantType:     TypeFeat,
+			wantSubject:  "drop v1",
+			wantBreaking: true,
+		},
+		{
+			name:        "jira prefix",
+			convention:  ConventionJira,
+			message:     "PROJ-123 feat(api): add login",
+			wantType:    TypeFeat,
+			wantScope:   "api",
+			wantSubject: "add login",
+		},
+		{
+			name:        "jira bracket prefix",
+			convention:  ConventionJira,
+			message:     "[PROJ-123]: fix: handle nil",
+			wantType:    TypeFix,
+			wantSubject: "handle nil",
+		},
+		{
+			name:        "jira without type",
+			convention:  ConventionJira,
+			message:     "PROJ-123 update readme",
+			wantSubject: "update readme",
+		},
+		{
+			name:        "jira without prefix",
+			convention:  ConventionJira,
+			message:     "fix: handle nil",
+			wantType:    TypeFix,
+			wantSubject: "handle nil",
+		},
+		{
+			name:         "regex",
+			convention:   ConventionRegex,
+			pattern:      `^(?P<type>[A-Z]+)(?P<breaking>\*)?: (?P<subject>.+)$`,
+			types:        map[string]string{"ADD": "feat"},
+			message:      "ADD*: new api",
+			wantType:     TypeFeat,
+			wantSubject:  "new api",
+			wantBreaking: true,
+		},
+		{
+			name:        "regex unmapped type",
+			convention:  ConventionRegex,
+			pattern:     `^(?P<type>[A-Z]+): (?P<subject>.+)$`,
+			message:     "FIX: handle nil",
+			wantType:    TypeFix,
+			wantSubject: "handle nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewCommitParser(string(tt.convention), tt.pattern, tt.types)
+			require.NoError(t, err)
+			c := parser.Parse("abc", tt.message)
+			assert.Equal(t, "abc", c.Hash)
+			assert.Equal(t, tt.wantType, c.Type)
+			assert.Equal(t, tt.wantScope, c.Scope)
+			assert.Equal(t, tt.wantSubject, c.Subject)
+			assert.Equal(t, tt.wantBreaking, c.Breaking)
+		})
+	}
+}
+
+func TestJiraParserReferences(t *testing.T) {
+	parser, err := NewCommitParser(string(ConventionJira), "", nil)
+	require.NoError(t, err)
+
+	c := parser.Parse("", "PROJ-123 feat: add login\n\nRefs: PROJ-123, #7")
+	assert.Equal(t, []Reference{
+		{Kind: ReferenceExternal, ID: "PROJ-123"},
+		{Kind: ReferenceIssue, ID: "7", Action: "refs"},
+	}, c.References)
+}
+
+func TestNewCommitParserInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		convention string
+		pattern    string
+		types      map[string]string
+	}{
+		{"unknown convention", "emoji", "", nil},
+		{"regex without pattern", "regex", "", nil},
+		{"invalid pattern", "regex", "(?P<type>", nil},
+		{"pattern without subject", "regex", `^(?P<type>\w+)`, nil},
+		{"pattern for preset", "conventional", `^(?P<type>\w+) (?P<subject>.+)$`, nil},
+		{"empty mapped type", "conventional", "", map[string]string{"feature": "!"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCommitParser(tt.convention, tt.pattern, tt.types)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ReferenceKind 表示引用的类型
+type ReferenceKind string
+
+const (
+	// ReferenceIssue 是 GitLab 议题: #123 或 group/project#123
+	ReferenceIssue ReferenceKind = "issue"
+	// ReferenceMergeRequest 是 GitLab 合并请求: !45 或 group/project!45
+	ReferenceMergeRequest ReferenceKind = "merge_request"
+	// ReferenceExternal 是外部议题跟踪系统中的议题，例如 Jira 的 PROJ-45
+	ReferenceExternal ReferenceKind = "external"
+)
+
+var (
+	// gitlabReferencePattern 匹配 GitLab 议题和合并请求引用，项目路径至少包含一个 /
+	gitlabReferencePattern = regexp.MustCompile(`(?:^|[\s(\[,;])((?:[\w.-]+/)+[\w.-]+)?([#!])(\d+)\b`)
+	// externalReferencePattern 匹配外部议题编号，只在议题相关的脚注中识别
+	externalReferencePattern = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+-\d+)\b`)
+	// numberPattern 匹配 "Refs #133" 形式的脚注值，# 是脚注的分隔符
+	numberPattern = regexp.MustCompile(`^\d+$`)
+)
+
+// issueTokens 是值为议题引用的脚注标记（小写）
+var issueTokens = map[string]bool{
+	"close": true, "closes": true, "closed": true,
+	"fix": true, "fixes": true, "fixed": true,
+	"resolve": true, "resolves": true, "resolved": true,
+	"implement": true, "implements": true,
+	"ref": true, "refs": true, "references": true,
+	"related": true, "related-to": true, "relates-to": true,
+	"see": true, "see-also": true, "part-of": true,
+	"issue": true, "issues": true,
+}
+
+// Reference 表示提交消息中对议题或合并请求的引用
+type Reference struct {
+	Kind ReferenceKind
+	// Project 是跨项目引用的项目路径，引用当前项目时为空
+	Project string
+	ID      string
+	// Action 是引用所在脚注的标记（小写），例如 closes 或 refs，出现在标题或正文中时为空
+	Action string
+}
+
+// String 返回引用在 GitLab 中的写法
+func (r Reference) String() string {
+	switch r.Kind {
+	case ReferenceIssue:
+		return r.Project + "#" + r.ID
+	case ReferenceMergeRequest:
+		return r.Project + "!" + r.ID
+	default:
+		return r.ID
+	}
+}
+
+// URL 返回引用指向的 GitLab 页面。projectURL 和 projectPath 是当前项目的 URL 和路径，
+// 用于确定 GitLab 实例的地址。外部议题和无法确定地址的引用返回空字符串。
+func (r Reference) URL(projectURL, projectPath string) string {
+	projectURL = strings.TrimSuffix(projectURL, "/")
+	if projectURL == "" {
+		return ""
+	}
+	base := projectURL
+	if r.Project != "" {
+		root := strings.TrimSuffix(projectURL, "/"+strings.Trim(projectPath, "/"))
+		if projectPath == "" || root == projectURL {
+			return ""
+		}
+		base = root + "/" + r.Project
+	}
+	switch r.Kind {
+	case ReferenceIssue:
+		return base + "/-/issues/" + r.ID
+	case ReferenceMergeRequest:
+		return base + "/-/merge_requests/" + r.ID
+	default:
+		return ""
+	}
+}
+
+// parseReferences 在 c.References 之后添加标题、正文和脚注中的引用，重复的引用只保留第一次出现
+func parseReferences(c *Commit) []Reference {
+	refs := make([]Reference, 0, len(c.References))
+	seen := make(map[string]bool)
+	add := func(ref Reference) {
+		key := string(ref.Kind) + ":" + ref.String()
+		if !seen[key] {
+			seen[key] = true
+			refs = append(refs, ref)
+		}
+	}
+	for _, ref := range c.References {
+		add(ref)
+	}
+
+	for _, text := range []string{c.Subject, c.Body} {
+		for _, ref := range gitlabReferences(text, "") {
+			add(ref)
+		}
+	}
+	for _, f := range c.Footers {
+		action := strings.ToLower(f.Token)
+		if !issueTokens[action] {
+			for _, ref := range gitlabReferences(f.Value, "") {
+				add(ref)
+			}
+			continue
+		}
+		if numberPattern.MatchString(f.Value) {
+			add(Reference{Kind: ReferenceIssue, ID: f.Value, Action: action})
+			continue
+		}
+		for _, ref := range gitlabReferences(f.Value, action) {
+			add(ref)
+		}
+		for _, m := range externalReferencePattern.FindAllStringSubmatch(f.Value, -1) {
+			add(Reference{Kind: ReferenceExternal, ID: m[1], Action: action})
+		}
+	}
+	return refs
+}
+
+// gitlabReferences 提取文本中的 GitLab 议题和合并请求引用
+func gitlabReferences(text, action string) []Reference {
+	refs := make([]Reference, 0)
+	for _, m := range gitlabReferencePattern.FindAllStringSubmatch(text, -1) {
+		kind := ReferenceIssue
+		if m[2] == "!" {
+			kind = ReferenceMergeRequest
+		}
+		refs = append(refs, Reference{Kind: kind, Project: m[1], ID: m[3], Action: action})
+	}
+	return refs
+}
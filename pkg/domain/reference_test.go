@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommitReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []Reference
+	}{
+		{
+			name:    "subject",
+			message: "fix: crash on empty input (#12)",
+			want:    []Reference{{Kind: ReferenceIssue, ID: "12"}},
+		},
+		{
+			name:    "footers",
+			message: "feat: login\n\nCloses #123\nRefs: PROJ-45, group/proj#12\nCo-authored-by: A <a@example.com>",
+			want: []Reference{
+				{Kind: ReferenceIssue, ID: "123", Action: "closes"},
+				{Kind: ReferenceIssue, Project: "group/proj", ID: "12", Action: "refs"},
+				{Kind: ReferenceExternal, ID: "PROJ-45", Action: "refs"},
+			},
+		},
+		{
+			name:    "merge request in body",
+			message: "feat: login\n\nFollow-up to !45 and group/sub/proj!7.\n\nFixes: #3",
+			want: []Reference{
+				{Kind: ReferenceMergeRequest, ID: "45"},
+				{Kind: ReferenceMergeRequest, Project: "group/sub/proj", ID: "7"},
+				{Kind: ReferenceIssue, ID: "3", Action: "fixes"},
+			},
+		},
+		{
+			name:    "duplicates",
+			message: "fix: crash (#12)\n\nCloses #12",
+			want:    []Reference{{Kind: ReferenceIssue, ID: "12"}},
+		},
+		{
+			name:    "not references",
+			message: "docs: use UTF-8 in section#2\n\nSigned-off-by: PROJ-1 <x@example.com>",
+			want:    []Reference{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseCommit("", tt.message).References)
+		})
+	}
+}
+
+func TestReferenceURL(t *testing.T) {
+	const projectURL = "https://gitlab.example.com/gitlab/group/proj"
+	const projectPath = "group/proj"
+
+	tests := []struct {
+		ref     Reference
+		text    string
+		url     string
+		noPaths string
+	}{
+		{Reference{Kind: ReferenceIssue, ID: "12"}, "#12", "https://gitlab.example.com/gitlab/group/proj/-/issues/12", "https://gitlab.example.com/gitlab/group/proj/-/issues/12"},
+		{Reference{Kind: ReferenceMergeRequest, ID: "45"}, "!45", "https://gitlab.example.com/gitlab/group/proj/-/merge_requests/45", "https://gitlab.example.com/gitlab/group/proj/-/merge_requests/45"},
+		{Reference{Kind: ReferenceIssue, Project: "other/app", ID: "3"}, "other/app#3", "https://gitlab.example.com/gitlab/other/app/-/issues/3", ""},
+		{Reference{Kind: ReferenceExternal, ID: "PROJ-45"}, "PROJ-45", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.text, tt.ref.String())
+			assert.Equal(t, tt.url, tt.ref.URL(projectURL+"/", projectPath))
+			// 不知道项目路径时无法确定其他项目的地址
+			assert.Equal(t, tt.noPaths, tt.ref.URL(projectURL, ""))
+			assert.Empty(t, tt.ref.URL("", projectPath))
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "sort"
+
 // Release 表示一个发布
 type Release struct {
 	Version *Version
@@ -7,6 +9,8 @@ type Release struct {
 	TagName string
 	Message string
 	Links   []ReleaseLink
+	// Explanation 说明下一个版本是怎样确定的，没有分析提交时为 nil
+	Explanation *Explanation
 }
 
 // ReleaseLink 表示发布中的下载链接
@@ -21,8 +25,61 @@ func NewRelease(version *Version, tagPrefix string) *Release {
 	return &Release{
 		Version: version,
 		Changes: make(map[string][]*Commit),
-		TagName: tagPrefix + version.Next.String(),
+		TagName: tagPrefix + version.NextString(),
+	}
+}
+
+// ListedChanges 返回类别中列在发布说明中的变更。预发布版本只列出之前的预发布版本中没有的提交，
+// 正式版本列出自上一个正式版本以来的所有提交
+func (r *Release) ListedChanges(category string) []*Commit {
+	if len(r.Version.Next.Pre) == 0 {
+		return r.Changes[category]
+	}
+	listed := make([]*Commit, 0, len(r.Changes[category]))
+	for _, c := range r.Changes[category] {
+		if !c.IsPreReleased() {
+			listed = append(listed, c)
+		}
+	}
+	return listed
+}
+
+// NoteGroup 是发布说明中的一个变更分组，Types 是分组包含的类别，例如 feat 或 breaking
+type NoteGroup struct {
+	Title string
+	Types []string
+}
+
+// NoteSection 是发布说明中的一节
+type NoteSection struct {
+	// Category 是没有配置分组时该节的类别，使用分组时为空
+	Category string
+	Title    string
+	Changes  []*Commit
+}
+
+// Sections 返回发布说明中有变更的各节。没有配置分组时每个类别一节，按 Categories 的顺序排列，标题为类别名称；
+// 配置了分组时按分组的顺序排列，不属于任何分组的类别不列出
+func (r *Release) Sections(groups []NoteGroup) []NoteSection {
+	sections := make([]NoteSection, 0)
+	if len(groups) == 0 {
+		for _, category := range r.Categories() {
+			if changes := r.ListedChanges(category); len(changes) > 0 {
+				sections = append(sections, NoteSection{Category: category, Title: category, Changes: changes})
+			}
+		}
+		return sections
+	}
+	for _, g := range groups {
+		changes := make([]*Commit, 0)
+		for _, category := range g.Types {
+			changes = append(changes, r.ListedChanges(category)...)
+		}
+		if len(changes) > 0 {
+			sections = append(sections, NoteSection{Title: g.Title, Changes: changes})
+		}
 	}
+	return sections
 }
 
 // AddChange 添加一个变更到发布中
@@ -33,6 +90,39 @@ func (r *Release) AddChange(category string, commit *Commit) {
 	r.Changes[category] = append(r.Changes[category], commit)
 }
 
+// categoryOrder 是发布说明中类别的顺序，其他类别按名称排在这些类别之后，other 排在最后
+var categoryOrder = []string{"breaking", "feat", "fix", "perf", "refactor", "revert", "docs", "style", "test", "chore"}
+
+// Categories 按固定的顺序返回有变更的类别，使发布说明和机器可读的输出保持稳定
+func (r *Release) Categories() []string {
+	rank := func(category string) int {
+		for i, c := range categoryOrder {
+			if c == category {
+				return i
+			}
+		}
+		if category == "other" {
+			return len(categoryOrder) + 1
+		}
+		return len(categoryOrder)
+	}
+
+	categories := make([]string, 0, len(r.Changes))
+	for category, changes := range r.Changes {
+		if len(changes) > 0 {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		ri, rj := rank(categories[i]), rank(categories[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return categories[i] < categories[j]
+	})
+	return categories
+}
+
 // AddLink 添加一个下载链接到发布中
 func (r *Release) AddLink(name, url, description string) {
 	r.Links = append(r.Links, ReleaseLink{
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseCategories(t *testing.T) {
+	release := NewRelease(NewVersion(time.Now()), "v")
+	for _, category := range []string{"other", "docs", "build", "fix", "breaking", "ci", "feat"} {
+		release.AddChange(category, NewCommit("abc", CommitType(category), "", "x", "", false))
+	}
+	release.Changes["perf"] = []*Commit{}
+
+	assert.Equal(t, []string{"breaking", "feat", "fix", "docs", "build", "ci", "other"}, release.Categories())
+}
+
+func TestReleaseListedChanges(t *testing.T) {
+	shipped := NewCommit("abc", TypeFeat, "", "in rc.1", "", false)
+	shipped.SetPreReleased(true)
+	fresh := NewCommit("def", TypeFeat, "", "after rc.1", "", false)
+
+	release := NewRelease(NewVersion(time.Now()), "v")
+	release.AddChange("feat", shipped)
+	release.AddChange("feat", fresh)
+
+	// 预发布版本只列出之前的预发布版本中没有的提交
+	release.Version.Next = semver.MustParse("1.4.0-rc.2")
+	assert.Equal(t, []*Commit{fresh}, release.ListedChanges("feat"))
+
+	// 正式版本列出所有提交
+	release.Version.Next = semver.MustParse("1.4.0")
+	assert.Equal(t, []*Commit{shipped, fresh}, release.ListedChanges("feat"))
+}
+
+func TestReleaseSections(t *testing.T) {
+	release := NewRelease(NewVersion(time.Now()), "v")
+	feat := NewCommit("abc", TypeFeat, "", "feature", "", false)
+	fix := NewCommit("def", TypeFix, "", "fix", "", false)
+	perf := NewCommit("123", CommitType("perf"), "", "faster", "", false)
+	docs := NewCommit("456", CommitType("docs"), "", "docs", "", false)
+	release.AddChange("feat", feat)
+	release.AddChange("fix", fix)
+	release.AddChange("perf", perf)
+	release.AddChange("docs", docs)
+
+	// 没有分组时每个类别一节
+	sections := release.Sections(nil)
+	require.Len(t, sections, 4)
+	assert.Equal(t, NoteSection{Category: "feat", Title: "feat", Changes: []*Commit{feat}}, sections[0])
+
+	// 分组按配置的顺序排列，没有变更的分组和不属于分组的类别不列出
+	sections = release.Sections([]NoteGroup{
+		{Title: "修复", Types: []string{"fix", "perf"}},
+		{Title: "破坏性变更", Types: []string{"breaking"}},
+		{Title: "新功能", Types: []string{"feat"}},
+	})
+	assert.Equal(t, []NoteSection{
+		{Title: "修复", Changes: []*Commit{fix, perf}},
+		{Title: "新功能", Changes: []*Commit{feat}},
+	}, sections)
+}
@@ -0,0 +1,195 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+)
+
+// VersionScheme 是版本号方案，决定标签中的版本怎样解析、显示和升级。
+// 所有方案都把版本表示为 semver.Version，以便比较版本和添加预发布版本标识
+type VersionScheme interface {
+	// Parse 解析去掉标签前缀后的版本
+	Parse(s string) (semver.Version, error)
+	// Format 返回版本在标签和发布中的形式
+	Format(v semver.Version) string
+	// Next 返回按升级级别 level 在日期 date 发布的下一个版本，level 为 NoBump 时返回 current
+	Next(current semver.Version, level BumpLevel, date time.Time) semver.Version
+}
+
+// SemVer 是默认的语义化版本方案
+type SemVer struct{}
+
+// Parse 解析语义化版本
+func (SemVer) Parse(s string) (semver.Version, error) {
+	return semver.Parse(s)
+}
+
+// Format 返回语义化版本的字符串形式
+func (SemVer) Format(v semver.Version) string {
+	return v.String()
+}
+
+// Next 按升级级别升级主版本号、次版本号或修订号
+func (SemVer) Next(current semver.Version, level BumpLevel, date time.Time) semver.Version {
+	return level.Apply(current)
+}
+
+// CalVer 是日历版本方案，例如 YYYY.MM.MICRO 的 2026.10.3 或 YY.0M.MICRO 的 26.10.0。
+// 年份保存在 Major，月份或周数保存在 Minor，MICRO 保存在 Patch。
+// 提交决定是否发布，发布时进入新的周期则 MICRO 从 0 开始，否则 MICRO 加 1
+type CalVer struct {
+	format string
+	year   string
+	period string
+}
+
+// CalVerFormats 是常用的日历版本格式，年份和周期的写法可以任意组合。
+// YYYY 是完整的年份，YY 和 0Y 是年份的后两位；MM 和 0M 是月份，WW 和 0W 是 ISO 周数，0 前缀表示补齐两位
+var CalVerFormats = []string{"YYYY.MM.MICRO", "YYYY.0M.MICRO", "YY.MM.MICRO", "YY.0M.MICRO", "0Y.0M.MICRO", "YYYY.WW.MICRO", "YY.0W.MICRO"}
+
+// NewCalVer 按格式 format 创建日历版本方案，格式为 <年份>.<月份或周数>.MICRO
+func NewCalVer(format string) (CalVer, error) {
+	parts := strings.Split(format, ".")
+	if len(parts) != 3 || parts[2] != "MICRO" {
+		return CalVer{}, fmt.Errorf("日历版本格式 %q 无效，格式为 <年份>.<月份或周数>.MICRO，例如 %s", format, strings.Join(CalVerFormats, "、"))
+	}
+	switch parts[0] {
+	case "YYYY", "YY", "0Y":
+	default:
+		return CalVer{}, fmt.Errorf("日历版本格式 %q 无效: 未知的年份 %s，可选值为 YYYY、YY、0Y", format, parts[0])
+	}
+	switch parts[1] {
+	case "MM", "0M", "WW", "0W":
+	default:
+		return CalVer{}, fmt.Errorf("日历版本格式 %q 无效: 未知的周期 %s，可选值为 MM、0M、WW、0W", format, parts[1])
+	}
+	return CalVer{format: format, year: parts[0], period: parts[1]}, nil
+}
+
+// String 返回日历版本的格式
+func (c CalVer) String() string {
+	return c.format
+}
+
+// Parse 解析日历版本，年份和周期必须符合格式，例如 YYYY 格式不接受 1.2.3
+func (c CalVer) Parse(s string) (semver.Version, error) {
+	core, suffix := s, ""
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core, suffix = s[:i], s[i:]
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semver.Version{}, fmt.Errorf("版本 %q 不符合日历版本格式 %s", s, c.format)
+	}
+	nums := make([]uint64, 3)
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil || p == "" {
+			return semver.Version{}, fmt.Errorf("版本 %q 不符合日历版本格式 %s", s, c.format)
+		}
+		nums[i] = n
+	}
+	if !c.validYear(parts[0], nums[0]) || !c.validPeriod(parts[1], nums[1]) || (len(parts[2]) > 1 && parts[2][0] == '0') {
+		return semver.Version{}, fmt.Errorf("版本 %q 不符合日历版本格式 %s", s, c.format)
+	}
+
+	// 预发布版本和构建元数据按语义化版本的规则解析
+	v, err := semver.Parse("0.0.0" + suffix)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("版本 %q 的预发布版本或构建元数据无效: %v", s, err)
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// validYear 检查年份的值和位数
+func (c CalVer) validYear(s string, n uint64) bool {
+	switch c.year {
+	case "YYYY":
+		return len(s) == 4 && n >= 1000
+	case "0Y":
+		return len(s) == 2
+	default:
+		return n < 100 && (len(s) == 1 || s[0] != '0')
+	}
+}
+
+// validPeriod 检查月份或周数的值和位数
+func (c CalVer) validPeriod(s string, n uint64) bool {
+	max := uint64(12)
+	if c.period == "WW" || c.period == "0W" {
+		max = 53
+	}
+	if n < 1 || n > max {
+		return false
+	}
+	if strings.HasPrefix(c.period, "0") {
+		return len(s) == 2
+	}
+	return s[0] != '0'
+}
+
+// Format 按格式返回日历版本，例如 26.01.0
+func (c CalVer) Format(v semver.Version) string {
+	year := strconv.FormatUint(v.Major, 10)
+	if c.year == "0Y" {
+		year = fmt.Sprintf("%02d", v.Major)
+	}
+	period := strconv.FormatUint(v.Minor, 10)
+	if strings.HasPrefix(c.period, "0") {
+		period = fmt.Sprintf("%02d", v.Minor)
+	}
+	s := fmt.Sprintf("%s.%s.%d", year, period, v.Patch)
+	if len(v.Pre) > 0 {
+		pre := make([]string, len(v.Pre))
+		for i, p := range v.Pre {
+			pre[i] = p.String()
+		}
+		s += "-" + strings.Join(pre, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// Next 返回在 date 发布的下一个版本。与 current 在同一个周期时 MICRO 加 1，否则从新周期的 0 开始。
+// 升级级别只决定是否发布，不影响版本号
+func (c CalVer) Next(current semver.Version, level BumpLevel, date time.Time) semver.Version {
+	if level == NoBump {
+		return current
+	}
+	y, period := date.Year(), uint64(date.Month())
+	if c.period == "WW" || c.period == "0W" {
+		// 周数属于 ISO 周所在的年份，例如 2024-12-30 属于 2025 年第 1 周
+		var week int
+		y, week = date.ISOWeek()
+		period = uint64(week)
+	}
+	year := uint64(y)
+	if c.year != "YYYY" {
+		year %= 100
+	}
+
+	next := semver.Version{Major: year, Minor: period}
+	if next.LT(semver.Version{Major: current.Major, Minor: current.Minor}) || (current.Major == year && current.Minor == period) {
+		// 同一个周期，或者当前版本晚于 date 时继续增加 MICRO
+		next = semver.Version{Major: current.Major, Minor: current.Minor, Patch: current.Patch + 1}
+	}
+	return next
+}
+
+// ParseVersionScheme 返回名称为 name 的版本号方案：semver，或者 calver 和日历版本格式 calverFormat
+func ParseVersionScheme(name, calverFormat string) (VersionScheme, error) {
+	switch name {
+	case "", "semver":
+		return SemVer{}, nil
+	case "calver":
+		return NewCalVer(calverFormat)
+	}
+	return nil, fmt.Errorf("未知的版本号方案 %q，可选值为 semver、calver", name)
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalVerParse(t *testing.T) {
+	tests := []struct {
+		format  string
+		version string
+		want    string
+		err     bool
+	}{
+		{format: "YYYY.MM.MICRO", version: "2026.10.3", want: "2026.10.3"},
+		{format: "YYYY.MM.MICRO", version: "2026.1.0-rc.1", want: "2026.1.0-rc.1"},
+		{format: "YYYY.0M.MICRO", version: "2026.01.2+build.5", want: "2026.01.2+build.5"},
+		{format: "YY.0M.MICRO", version: "26.10.0", want: "26.10.0"},
+		{format: "0Y.0M.MICRO", version: "05.03.1", want: "05.03.1"},
+		{format: "YYYY.WW.MICRO", version: "2026.53.0", want: "2026.53.0"},
+		{format: "YYYY.MM.MICRO", version: "1.2.3", err: true},
+		{format: "YYYY.MM.MICRO", version: "2026.13.0", err: true},
+		{format: "YYYY.MM.MICRO", version: "2026.01.0", err: true},
+		{format: "YYYY.0M.MICRO", version: "2026.1.0", err: true},
+		{format: "YYYY.MM.MICRO", version: "2026.10.01", err: true},
+		{format: "YYYY.MM.MICRO", version: "2026.10", err: true},
+		{format: "YY.MM.MICRO", version: "2026.10.0", err: true},
+		{format: "YYYY.MM.MICRO", version: "2026.10.0-", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.version, func(t *testing.T) {
+			scheme, err := NewCalVer(tt.format)
+			require.NoError(t, err)
+			v, err := scheme.Parse(tt.version)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, scheme.Format(v))
+		})
+	}
+}
+
+func TestCalVerNext(t *testing.T) {
+	oct := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format  string
+		current string
+		level   BumpLevel
+		date    time.Time
+		want    string
+	}{
+		// 同一个周期内 MICRO 加 1，升级级别不影响版本号
+		{"YYYY.MM.MICRO", "2026.10.3", BumpPatch, oct, "2026.10.4"},
+		{"YYYY.MM.MICRO", "2026.10.3", BumpMajor, oct, "2026.10.4"},
+		// 新的周期从 0 开始
+		{"YYYY.MM.MICRO", "2026.9.7", BumpMinor, oct, "2026.10.0"},
+		{"YY.0M.MICRO", "25.12.2", BumpPatch, oct, "26.10.0"},
+		{"YYYY.MM.MICRO", "0.0.0", BumpMinor, oct, "2026.10.0"},
+		// 没有会触发发布的提交
+		{"YYYY.MM.MICRO", "2026.9.7", NoBump, oct, "2026.9.7"},
+		// 当前版本晚于发布日期时继续增加 MICRO
+		{"YYYY.MM.MICRO", "2026.11.0", BumpPatch, oct, "2026.11.1"},
+		// ISO 周数
+		{"YYYY.WW.MICRO", "2026.1.0", BumpPatch, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), "2026.1.1"},
+		{"YYYY.WW.MICRO", "2024.52.0", BumpPatch, time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC), "2025.1.0"},
+	}
+	for _, tt := range tests {
+		scheme, err := NewCalVer(tt.format)
+		require.NoError(t, err)
+		current := semver.Version{}
+		if tt.current != "0.0.0" {
+			current, err = scheme.Parse(tt.current)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, tt.want, scheme.Format(scheme.Next(current, tt.level, tt.date)), "%s %s", tt.format, tt.current)
+	}
+}
+
+func TestParseVersionScheme(t *testing.T) {
+	scheme, err := ParseVersionScheme("", "")
+	require.NoError(t, err)
+	assert.Equal(t, SemVer{}, scheme)
+
+	scheme, err = ParseVersionScheme("calver", "YY.0M.MICRO")
+	require.NoError(t, err)
+	assert.Equal(t, "YY.0M.MICRO", scheme.(CalVer).String())
+
+	for _, format := range []string{"YYYY.MM", "YYYY.DD.MICRO", "YYY.MM.MICRO", "YYYY.MM.PATCH"} {
+		_, err = ParseVersionScheme("calver", format)
+		assert.Error(t, err, format)
+	}
+	_, err = ParseVersionScheme("romver", "")
+	assert.Error(t, err)
+}
+
+func TestVersionScheme(t *testing.T) {
+	scheme, err := NewCalVer("YYYY.0M.MICRO")
+	require.NoError(t, err)
+
+	v := NewVersion(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	v.SetScheme(scheme)
+	v.SetCurrent(semver.Version{Major: 2026, Minor: 1, Patch: 4})
+	assert.Equal(t, "2026.01.4", v.NextString())
+
+	v.Bump(BumpPatch)
+	assert.Equal(t, "2026.02.0", v.NextString())
+	assert.Equal(t, "v2026.02.0", NewRelease(v, "v").TagName)
+}
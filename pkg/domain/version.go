@@ -1,16 +1,31 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/blang/semver"
 )
 
-// Version 表示语义化版本
+// Version 表示当前版本和下一个版本，版本号方案为 nil 时使用语义化版本
 type Version struct {
 	Current semver.Version
 	Next    semver.Version
-	Level   BumpLevel
+	// Level 是实际应用到 Current 的升级级别
+	Level BumpLevel
+	// CommitLevel 是所有未发布提交中最高的升级级别
+	CommitLevel BumpLevel
+	Options     BumpOptions
+	// ReleaseAs 是 Release-As 脚注或 --release-as 指定的下一个版本，为 nil 时根据提交计算
+	ReleaseAs *semver.Version
+	// ReleaseAsSource 说明 ReleaseAs 的来源，例如 --release-as 或 Release-As 脚注所在的提交
+	ReleaseAsSource string
+	// Channel 是发布渠道的名称，没有按渠道发布时为空
+	Channel string
+	// Scheme 是版本号方案，为 nil 时使用 SemVer
+	Scheme VersionScheme
+	// Date 是发布日期，日历版本根据它确定周期
+	Date time.Time
 }
 
 // BumpLevel 表示版本升级级别
@@ -23,31 +38,167 @@ const (
 	BumpMajor
 )
 
+// String 返回升级级别的名称
+func (l BumpLevel) String() string {
+	switch l {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText 把升级级别编码为名称，例如 JSON 中的 "minor"
+func (l BumpLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Apply 把升级级别应用到指定版本，返回不含预发布和构建元数据的新版本
+func (l BumpLevel) Apply(v semver.Version) semver.Version {
+	next := semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch l {
+	case BumpMajor:
+		next.Major++
+		next.Minor = 0
+		next.Patch = 0
+	case BumpMinor:
+		next.Minor++
+		next.Patch = 0
+	case BumpPatch:
+		next.Patch++
+	default:
+		next.Pre = v.Pre
+		next.Build = v.Build
+	}
+	return next
+}
+
+// BumpOptions 控制如何把提交的升级级别应用到当前版本
+type BumpOptions struct {
+	// InitialDevelopment 为 true 且当前版本低于 1.0.0 时，破坏性变更只升级次版本号
+	InitialDevelopment bool
+	// BumpPatch 为 true 时，没有提交触发升级也会升级补丁版本号
+	BumpPatch bool
+}
+
 // NewVersion 创建一个新的版本对象
 func NewVersion(t time.Time) *Version {
 	return &Version{
 		Current: semver.Version{},
 		Next:    semver.Version{},
 		Level:   NoBump,
+		Date:    t,
+	}
+}
+
+// SetScheme 设置版本号方案
+func (v *Version) SetScheme(scheme VersionScheme) {
+	v.Scheme = scheme
+	v.update()
+}
+
+// scheme 返回使用的版本号方案
+func (v *Version) scheme() VersionScheme {
+	if v.Scheme == nil {
+		return SemVer{}
+	}
+	return v.Scheme
+}
+
+// Format 按版本号方案返回 sv 的字符串形式
+func (v *Version) Format(sv semver.Version) string {
+	return v.scheme().Format(sv)
+}
+
+// CurrentString 按版本号方案返回当前版本
+func (v *Version) CurrentString() string {
+	return v.Format(v.Current)
+}
+
+// NextString 按版本号方案返回下一个版本
+func (v *Version) NextString() string {
+	return v.Format(v.Next)
+}
+
+// SetCurrent 设置当前版本，并以其作为计算下一个版本的基准
+func (v *Version) SetCurrent(current semver.Version) {
+	v.Current = current
+	v.update()
+}
+
+// SetOptions 设置版本计算策略
+func (v *Version) SetOptions(opts BumpOptions) {
+	v.Options = opts
+	v.update()
+}
+
+// SetReleaseAs 指定下一个版本，不再根据提交计算。target 必须大于当前版本。
+// source 说明指定版本的来源，在执行计划中显示。
+func (v *Version) SetReleaseAs(target semver.Version, source string) error {
+	if !target.GT(v.Current) {
+		return fmt.Errorf("%s 指定的版本 %s 必须大于当前版本 %s", source, v.Format(target), v.CurrentString())
 	}
+	v.ReleaseAs = &target
+	v.ReleaseAsSource = source
+	v.update()
+	return nil
 }
 
-// Bump 根据指定的级别升级版本
+// Bump 记录一个提交的升级级别。
+// 无论调用多少次，最终只把最高的级别应用到 Current 一次。
 func (v *Version) Bump(level BumpLevel) {
-	if level > v.Level {
-		v.Level = level
+	if level > v.CommitLevel {
+		v.CommitLevel = level
+	}
+	v.update()
+}
+
+// update 根据 CommitLevel 和 Options 重新计算 Level，再按版本号方案计算 Next。
+// 指定了 ReleaseAs 时 Next 为指定的版本，Level 为从 Current 到 Next 的升级级别。
+// 之前设置的预发布版本和构建元数据会被清除。
+func (v *Version) update() {
+	if v.ReleaseAs != nil {
+		v.Level = levelBetween(v.Current, *v.ReleaseAs)
+		v.Next = *v.ReleaseAs
+		return
+	}
+	level := v.CommitLevel
+	if level == NoBump && v.Options.BumpPatch {
+		level = BumpPatch
 	}
+	if level == BumpMajor && v.Options.InitialDevelopment && v.Current.Major == 0 {
+		level = BumpMinor
+	}
+	v.Level = level
+	v.Next = v.scheme().Next(v.Current, level, v.Date)
+}
 
-	switch v.Level {
-	case BumpMajor:
-		v.Next.Major++
-		v.Next.Minor = 0
-		v.Next.Patch = 0
-	case BumpMinor:
-		v.Next.Minor++
-		v.Next.Patch = 0
-	case BumpPatch:
-		v.Next.Patch++
+// levelBetween 返回从 current 升级到更高的 next 的级别。
+// 只有预发布版本不同时（例如 1.0.0-rc.1 到 1.0.0）视为补丁版本升级。
+func levelBetween(current, next semver.Version) BumpLevel {
+	switch {
+	case next.Major != current.Major:
+		return BumpMajor
+	case next.Minor != current.Minor:
+		return BumpMinor
+	default:
+		return BumpPatch
+	}
+}
+
+// SetChannel 记录发布渠道，并把渠道的预发布版本标识和构建元数据设置到 Next。
+// pre 或 build 为空时保持 Next 中原有的部分，例如 Release-As 指定的预发布版本
+func (v *Version) SetChannel(channel string, pre []semver.PRVersion, build []string) {
+	v.Channel = channel
+	if len(pre) > 0 {
+		v.Next.Pre = pre
+	}
+	if len(build) > 0 {
+		v.Next.Build = build
 	}
 }
 
@@ -0,0 +1,208 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		levels    []BumpLevel
+		opts      BumpOptions
+		wantNext  string
+		wantLevel BumpLevel
+	}{
+		{
+			name:      "no commits",
+			current:   "1.2.3",
+			wantNext:  "1.2.3",
+			wantLevel: NoBump,
+		},
+		{
+			name:      "ten fixes bump patch once",
+			current:   "1.2.3",
+			levels:    []BumpLevel{BumpPatch, BumpPatch, BumpPatch, BumpPatch, BumpPatch, BumpPatch, BumpPatch, BumpPatch, BumpPatch, BumpPatch},
+			wantNext:  "1.2.4",
+			wantLevel: BumpPatch,
+		},
+		{
+			name:      "feature and fixes",
+			current:   "1.2.3",
+			levels:    []BumpLevel{BumpPatch, BumpMinor, BumpPatch, NoBump},
+			wantNext:  "1.3.0",
+			wantLevel: BumpMinor,
+		},
+		{
+			name:      "breaking change",
+			current:   "1.2.3",
+			levels:    []BumpLevel{BumpMinor, BumpMajor, BumpPatch},
+			wantNext:  "2.0.0",
+			wantLevel: BumpMajor,
+		},
+		{
+			name:      "only non bumping commits",
+			current:   "1.2.3",
+			levels:    []BumpLevel{NoBump, NoBump},
+			wantNext:  "1.2.3",
+			wantLevel: NoBump,
+		},
+		{
+			name:      "initial development turns breaking into minor",
+			current:   "0.4.2",
+			levels:    []BumpLevel{BumpMajor, BumpPatch},
+			opts:      BumpOptions{InitialDevelopment: true},
+			wantNext:  "0.5.0",
+			wantLevel: BumpMinor,
+		},
+		{
+			name:      "initial development without tag",
+			current:   "0.0.0",
+			levels:    []BumpLevel{BumpMajor},
+			opts:      BumpOptions{InitialDevelopment: true},
+			wantNext:  "0.1.0",
+			wantLevel: BumpMinor,
+		},
+		{
+			name:      "initial development is ignored from 1.0.0",
+			current:   "1.0.0",
+			levels:    []BumpLevel{BumpMajor},
+			opts:      BumpOptions{InitialDevelopment: true},
+			wantNext:  "2.0.0",
+			wantLevel: BumpMajor,
+		},
+		{
+			name:      "leaving initial development",
+			current:   "0.9.1",
+			levels:    []BumpLevel{BumpMajor},
+			wantNext:  "1.0.0",
+			wantLevel: BumpMajor,
+		},
+		{
+			name:      "bump patch without changes",
+			current:   "1.2.3",
+			levels:    []BumpLevel{NoBump},
+			opts:      BumpOptions{BumpPatch: true},
+			wantNext:  "1.2.4",
+			wantLevel: BumpPatch,
+		},
+		{
+			name:      "bump patch does not lower a feature",
+			current:   "1.2.3",
+			levels:    []BumpLevel{BumpMinor},
+			opts:      BumpOptions{BumpPatch: true},
+			wantNext:  "1.3.0",
+			wantLevel: BumpMinor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVersion(time.Now())
+			v.SetCurrent(semver.MustParse(tt.current))
+			v.SetOptions(tt.opts)
+			for _, l := range tt.levels {
+				v.Bump(l)
+			}
+			assert.Equal(t, tt.wantNext, v.Next.String())
+			assert.Equal(t, tt.wantLevel, v.Level)
+			assert.Equal(t, tt.current, v.Current.String())
+		})
+	}
+}
+
+func TestVersionOptionsAfterBump(t *testing.T) {
+	v := NewVersion(time.Now())
+	v.SetCurrent(semver.MustParse("0.3.0"))
+	v.Bump(BumpMajor)
+	assert.Equal(t, "1.0.0", v.Next.String())
+
+	v.SetOptions(BumpOptions{InitialDevelopment: true})
+	assert.Equal(t, "0.4.0", v.Next.String())
+	assert.Equal(t, BumpMajor, v.CommitLevel)
+}
+
+func TestBumpLevelString(t *testing.T) {
+	assert.Equal(t, "none", NoBump.String())
+	assert.Equal(t, "patch", BumpPatch.String())
+	assert.Equal(t, "minor", BumpMinor.String())
+	assert.Equal(t, "major", BumpMajor.String())
+}
+
+func TestVersionSetReleaseAs(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		target    string
+		wantLevel BumpLevel
+		wantErr   bool
+	}{
+		{"major", "1.2.3", "2.0.0", BumpMajor, false},
+		{"minor", "1.2.3", "1.5.0", BumpMinor, false},
+		{"patch", "1.2.3", "1.2.10", BumpPatch, false},
+		{"release of pre-release", "1.0.0-rc.1", "1.0.0", BumpPatch, false},
+		{"equal", "1.2.3", "1.2.3", NoBump, true},
+		{"lower", "1.2.3", "1.1.0", NoBump, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVersion(time.Now())
+			v.SetCurrent(semver.MustParse(tt.current))
+			err := v.SetReleaseAs(semver.MustParse(tt.target), "--release-as")
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, v.ReleaseAs)
+				return
+			}
+			assert.NoError(t, err)
+			// 指定的版本不受提交的升级级别和版本计算策略影响
+			v.Bump(BumpMinor)
+			v.SetOptions(BumpOptions{InitialDevelopment: true})
+			assert.Equal(t, tt.target, v.Next.String())
+			assert.Equal(t, tt.wantLevel, v.Level)
+			assert.Equal(t, "--release-as", v.ReleaseAsSource)
+		})
+	}
+}
+
+func TestExplanationDecide(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   string
+		opts      BumpOptions
+		level     BumpLevel
+		releaseAs string
+		wantNext  string
+		wantAdj   int
+	}{
+		{"no adjustment", "1.2.3", BumpOptions{}, BumpMinor, "", "1.3.0", 0},
+		{"bump patch", "1.2.3", BumpOptions{BumpPatch: true}, NoBump, "", "1.2.4", 1},
+		{"initial development", "0.4.0", BumpOptions{InitialDevelopment: true}, BumpMajor, "", "0.5.0", 1},
+		{"release as", "1.2.3", BumpOptions{}, BumpPatch, "2.0.0", "2.0.0", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVersion(time.Now())
+			v.SetCurrent(semver.MustParse(tt.current))
+			v.SetOptions(tt.opts)
+			v.Bump(tt.level)
+			if tt.releaseAs != "" {
+				assert.NoError(t, v.SetReleaseAs(semver.MustParse(tt.releaseAs), "--release-as"))
+			}
+
+			e := &Explanation{}
+			e.Decide(v)
+			assert.Equal(t, tt.current, e.Decision.Current)
+			assert.Equal(t, tt.level, e.Decision.CommitLevel)
+			assert.Equal(t, tt.wantNext, e.Decision.Next)
+			assert.Len(t, e.Decision.Adjustments, tt.wantAdj)
+			assert.Equal(t, tt.releaseAs, e.Decision.ReleaseAs)
+		})
+	}
+}
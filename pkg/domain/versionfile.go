@@ -0,0 +1,317 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// VersionFileType 是版本文件的类型，决定文件中的哪个版本号会被更新
+type VersionFileType string
+
+const (
+	// VersionFileNPM 是 package.json，更新顶层的 version 字段
+	VersionFileNPM VersionFileType = "npm"
+	// VersionFileHelm 是 Helm 的 Chart.yaml，更新 version 和 appVersion
+	VersionFileHelm VersionFileType = "helm"
+	// VersionFileMaven 是 pom.xml，更新 project 的 version，不修改 parent 和依赖的版本
+	VersionFileMaven VersionFileType = "maven"
+	// VersionFilePython 是 pyproject.toml，更新 [project] 或 [tool.poetry] 中的 version
+	VersionFilePython VersionFileType = "python"
+	// VersionFileCargo 是 Cargo.toml，更新 [package] 或 [workspace.package] 中的 version
+	VersionFileCargo VersionFileType = "cargo"
+	// VersionFilePlain 是只包含版本号的文本文件，例如 VERSION
+	VersionFilePlain VersionFileType = "plain"
+	// VersionFileGo 是 Go 源文件，更新 const Version = "..." 声明
+	VersionFileGo VersionFileType = "go"
+	// VersionFileRegex 使用配置的正则表达式，更新命名分组 version 匹配的内容
+	VersionFileRegex VersionFileType = "regex"
+	// VersionFileMarker 更新包含标记的行中的第一个版本号
+	VersionFileMarker VersionFileType = "marker"
+)
+
+// VersionFileTypes 是所有可用的版本文件类型
+var VersionFileTypes = []VersionFileType{
+	VersionFileNPM, VersionFileHelm, VersionFileMaven, VersionFilePython, VersionFileCargo,
+	VersionFilePlain, VersionFileGo, VersionFileRegex, VersionFileMarker,
+}
+
+// versionFileNames 是可以根据文件名推断类型的文件
+var versionFileNames = map[string]VersionFileType{
+	"package.json":   VersionFileNPM,
+	"Chart.yaml":     VersionFileHelm,
+	"pom.xml":        VersionFileMaven,
+	"pyproject.toml": VersionFilePython,
+	"Cargo.toml":     VersionFileCargo,
+	"VERSION":        VersionFilePlain,
+}
+
+var (
+	// helmPattern 匹配 Chart.yaml 顶层的 version 和 appVersion，保留引号和注释
+	helmPattern = regexp.MustCompile(`(?m)^(?:version|appVersion):[ \t]*["']?(?P<version>[^"'\s#]*)`)
+	// goVersionPattern 匹配 Go 源文件中的 const Version = "..." 和 const 块中的 Version = "..."
+	goVersionPattern = regexp.MustCompile(`(?m)^[ \t]*(?:const[ \t]+)?Version(?:[ \t]+string)?[ \t]*=[ \t]*"(?P<version>[^"\n]*)"`)
+	// tomlVersionPattern 匹配 TOML 表中的 version = "..."
+	tomlVersionPattern = regexp.MustCompile(`^[ \t]*version[ \t]*=[ \t]*["'](?P<version>[^"'\n]*)["']`)
+	// tomlTablePattern 匹配 TOML 表的标题行，例如 [package]
+	tomlTablePattern = regexp.MustCompile(`^[ \t]*\[([^\[\]]+)\][ \t]*(?:#.*)?$`)
+	// markerVersionPattern 匹配标记行中的版本号，包括预发布版本和构建元数据
+	markerVersionPattern = regexp.MustCompile(`\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`)
+)
+
+// VersionFile 是发布时需要更新版本号的文件
+type VersionFile struct {
+	// Path 是文件相对于仓库根目录的路径
+	Path string
+	Type VersionFileType
+	// Pattern 是 regex 类型匹配版本号的正则表达式，必须包含命名分组 version
+	Pattern *regexp.Regexp
+	// Marker 是 marker 类型的标记，例如 x-release-version
+	Marker string
+}
+
+// NewVersionFile 创建版本文件。typ 为空时根据文件名推断类型，
+// regex 类型需要包含命名分组 version 的 pattern，marker 类型需要 marker
+func NewVersionFile(filePath string, typ VersionFileType, pattern, marker string) (VersionFile, error) {
+	if strings.TrimSpace(filePath) == "" {
+		return VersionFile{}, fmt.Errorf("版本文件的路径不能为空")
+	}
+	if typ == "" {
+		base := path.Base(filePath)
+		typ = versionFileNames[base]
+		if typ == "" && path.Ext(base) == ".go" {
+			typ = VersionFileGo
+		}
+		if typ == "" {
+			return VersionFile{}, fmt.Errorf("不能根据文件名推断 %s 的类型，请指定类型，可选值为 %s", filePath, joinVersionFileTypes())
+		}
+	}
+
+	f := VersionFile{Path: filePath, Type: typ}
+	switch typ {
+	case VersionFileRegex:
+		if pattern == "" {
+			return VersionFile{}, fmt.Errorf("版本文件 %s 的 regex 类型需要正则表达式", filePath)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return VersionFile{}, fmt.Errorf("版本文件 %s 的正则表达式无效: %v", filePath, err)
+		}
+		if re.SubexpIndex("version") < 0 {
+			return VersionFile{}, fmt.Errorf("版本文件 %s 的正则表达式必须包含命名分组 version", filePath)
+		}
+		f.Pattern = re
+	case VersionFileMarker:
+		if marker == "" {
+			return VersionFile{}, fmt.Errorf("版本文件 %s 的 marker 类型需要标记", filePath)
+		}
+		f.Marker = marker
+	case VersionFileNPM, VersionFileHelm, VersionFileMaven, VersionFilePython, VersionFileCargo, VersionFilePlain, VersionFileGo:
+	default:
+		return VersionFile{}, fmt.Errorf("版本文件 %s 的类型 %q 无效，可选值为 %s", filePath, typ, joinVersionFileTypes())
+	}
+	if typ != VersionFileRegex && pattern != "" {
+		return VersionFile{}, fmt.Errorf("版本文件 %s 的正则表达式只能用于 regex 类型", filePath)
+	}
+	if typ != VersionFileMarker && marker != "" {
+		return VersionFile{}, fmt.Errorf("版本文件 %s 的标记只能用于 marker 类型", filePath)
+	}
+	return f, nil
+}
+
+// joinVersionFileTypes 返回以顿号分隔的版本文件类型
+func joinVersionFileTypes() string {
+	names := make([]string, len(VersionFileTypes))
+	for i, t := range VersionFileTypes {
+		names[i] = string(t)
+	}
+	return strings.Join(names, "、")
+}
+
+// Update 返回把 content 中的版本号更新为 version 后的内容，保留文件的其余部分和格式。
+// 文件中没有找到要更新的版本号时返回错误
+func (f VersionFile) Update(content []byte, version string) ([]byte, error) {
+	var (
+		updated []byte
+		n       int
+		err     error
+	)
+	switch f.Type {
+	case VersionFileNPM:
+		updated, n, err = updateJSONVersion(content, version)
+	case VersionFileHelm:
+		updated, n = replaceVersionGroup(content, helmPattern, version)
+	case VersionFileMaven:
+		updated, n, err = updateMavenVersion(content, version)
+	case VersionFilePython:
+		updated, n = updateTOMLVersion(content, version, "project", "tool.poetry")
+	case VersionFileCargo:
+		updated, n = updateTOMLVersion(content, version, "package", "workspace.package")
+	case VersionFilePlain:
+		updated, n = updatePlainVersion(content, version), 1
+	case VersionFileGo:
+		updated, n = replaceVersionGroup(content, goVersionPattern, version)
+	case VersionFileRegex:
+		updated, n = replaceVersionGroup(content, f.Pattern, version)
+	case VersionFileMarker:
+		updated, n = updateMarkerVersion(content, f.Marker, version)
+	default:
+		return nil, fmt.Errorf("版本文件 %s 的类型 %q 无效", f.Path, f.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析版本文件 %s 失败: %v", f.Path, err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("版本文件 %s 中没有找到 %s 类型的版本号", f.Path, f.Type)
+	}
+	return updated, nil
+}
+
+// replaceVersionGroup 把 re 的所有匹配中命名分组 version 的内容替换为 version，返回替换的次数
+func replaceVersionGroup(content []byte, re *regexp.Regexp, version string) ([]byte, int) {
+	group := re.SubexpIndex("version")
+	matches := re.FindAllSubmatchIndex(content, -1)
+	var b bytes.Buffer
+	last, n := 0, 0
+	for _, m := range matches {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		b.Write(content[last:start])
+		b.WriteString(version)
+		last = end
+		n++
+	}
+	b.Write(content[last:])
+	return b.Bytes(), n
+}
+
+// updateJSONVersion 更新 JSON 文件顶层对象的 version 字段，不修改嵌套对象中的 version
+func updateJSONVersion(content []byte, version string) ([]byte, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	depth, isKey, key := 0, true, ""
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return content, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				isKey = true
+			}
+			continue
+		}
+		if depth != 1 {
+			continue
+		}
+		if isKey {
+			key, _ = tok.(string)
+			isKey = false
+			continue
+		}
+		isKey = true
+		if key != "version" {
+			continue
+		}
+		current, ok := tok.(string)
+		if !ok {
+			return nil, 0, fmt.Errorf("version 字段不是字符串")
+		}
+		// InputOffset 位于字符串的结束引号之后
+		end := int(dec.InputOffset()) - 1
+		start := bytes.LastIndexByte(content[:end], '"') + 1
+		if string(content[start:end]) != current {
+			return nil, 0, fmt.Errorf("version 字段包含转义字符")
+		}
+		return append(append(append([]byte{}, content[:start]...), version...), content[end:]...), 1, nil
+	}
+}
+
+// updateMavenVersion 更新 pom.xml 中 project 元素的 version 子元素
+func updateMavenVersion(content []byte, version string) ([]byte, int, error) {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	stack := make([]string, 0)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return content, 0, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if len(stack) != 2 || stack[0] != "project" || t.Name.Local != "version" {
+				continue
+			}
+			start := int(dec.InputOffset())
+			end := start
+			next, err := dec.Token()
+			if err != nil {
+				return nil, 0, err
+			}
+			if _, ok := next.(xml.CharData); ok {
+				end = int(dec.InputOffset())
+			}
+			return append(append(append([]byte{}, content[:start]...), version...), content[end:]...), 1, nil
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// updateTOMLVersion 更新 TOML 文件中指定表的 version 键
+func updateTOMLVersion(content []byte, version string, tables ...string) ([]byte, int) {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	table, n := "", 0
+	for i, line := range lines {
+		if m := tomlTablePattern.FindSubmatch(bytes.TrimRight(line, "\r\n")); m != nil {
+			table = strings.TrimSpace(string(m[1]))
+			continue
+		}
+		for _, t := range tables {
+			if table == t && tomlVersionPattern.Match(line) {
+				var updated int
+				lines[i], updated = replaceVersionGroup(line, tomlVersionPattern, version)
+				n += updated
+			}
+		}
+	}
+	return bytes.Join(lines, nil), n
+}
+
+// updatePlainVersion 把只包含版本号的文件替换为 version，保留结尾的换行
+func updatePlainVersion(content []byte, version string) []byte {
+	trimmed := bytes.TrimRight(content, "\r\n")
+	return append([]byte(version), content[len(trimmed):]...)
+}
+
+// updateMarkerVersion 更新包含 marker 的每一行中的第一个版本号
+func updateMarkerVersion(content []byte, marker, version string) ([]byte, int) {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	n := 0
+	for i, line := range lines {
+		if !bytes.Contains(line, []byte(marker)) {
+			continue
+		}
+		if loc := markerVersionPattern.FindIndex(line); loc != nil {
+			lines[i] = append(append(append([]byte{}, line[:loc[0]]...), version...), line[loc[1]:]...)
+			n++
+		}
+	}
+	return bytes.Join(lines, nil), n
+}
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVersionFile(t *testing.T) {
+	tests := []struct {
+		path    string
+		typ     VersionFileType
+		pattern string
+		marker  string
+		want    VersionFileType
+		err     bool
+	}{
+		{path: "package.json", want: VersionFileNPM},
+		{path: "charts/app/Chart.yaml", want: VersionFileHelm},
+		{path: "pom.xml", want: VersionFileMaven},
+		{path: "pyproject.toml", want: VersionFilePython},
+		{path: "Cargo.toml", want: VersionFileCargo},
+		{path: "VERSION", want: VersionFilePlain},
+		{path: "internal/version/version.go", want: VersionFileGo},
+		{path: "version.txt", typ: VersionFilePlain, want: VersionFilePlain},
+		{path: "deploy.yml", typ: VersionFileRegex, pattern: `image: app:(?P<version>\S+)`, want: VersionFileRegex},
+		{path: "README.md", typ: VersionFileMarker, marker: "x-release-version", want: VersionFileMarker},
+		{path: "README.md", err: true},
+		{path: "", typ: VersionFilePlain, err: true},
+		{path: "a.txt", typ: "gradle", err: true},
+		{path: "a.txt", typ: VersionFileRegex, err: true},
+		{path: "a.txt", typ: VersionFileRegex, pattern: `app:(\S+)`, err: true},
+		{path: "a.txt", typ: VersionFileRegex, pattern: `(?P<version>`, err: true},
+		{path: "a.txt", typ: VersionFileMarker, err: true},
+		{path: "package.json", marker: "x", err: true},
+		{path: "package.json", pattern: `(?P<version>.+)`, err: true},
+	}
+	for _, tt := range tests {
+		f, err := NewVersionFile(tt.path, tt.typ, tt.pattern, tt.marker)
+		if tt.err {
+			assert.Error(t, err, tt.path)
+			continue
+		}
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.want, f.Type, tt.path)
+	}
+}
+
+func TestVersionFileUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		typ     VersionFileType
+		pattern string
+		marker  string
+		content string
+		want    string
+	}{
+		{
+			name:    "npm",
+			path:    "package.json",
+			content: "{\n  \"name\": \"app\",\n  \"config\": {\"version\": \"0.0.1\"},\n  \"version\": \"1.2.3\",\n  \"dependencies\": {\"lib\": \"^1.0.0\"}\n}\n",
+			want:    "{\n  \"name\": \"app\",\n  \"config\": {\"version\": \"0.0.1\"},\n  \"version\": \"1.3.0\",\n  \"dependencies\": {\"lib\": \"^1.0.0\"}\n}\n",
+		},
+		{
+			name:    "helm",
+			path:    "Chart.yaml",
+			content: "apiVersion: v2\nname: app\nversion: 1.2.3 # chart\nappVersion: \"1.2.3\"\ndependencies:\n  - name: redis\n    version: 17.0.0\n",
+			want:    "apiVersion: v2\nname: app\nversion: 1.3.0 # chart\nappVersion: \"1.3.0\"\ndependencies:\n  - name: redis\n    version: 17.0.0\n",
+		},
+		{
+			name: "maven",
+			path: "pom.xml",
+			content: "<?xml version=\"1.0\"?>\n<project xmlns=\"http://maven.apache.org/POM/4.0.0\">\n" +
+				"  <parent><version>2.0.0</version></parent>\n  <version>1.2.3</version>\n" +
+				"  <dependencies><dependency><version>3.0.0</version></dependency></dependencies>\n</project>\n",
+			want: "<?xml version=\"1.0\"?>\n<project xmlns=\"http://maven.apache.org/POM/4.0.0\">\n" +
+				"  <parent><version>2.0.0</version></parent>\n  <version>1.3.0</version>\n" +
+				"  <dependencies><dependency><version>3.0.0</version></dependency></dependencies>\n</project>\n",
+		},
+		{
+			name:    "python",
+			path:    "pyproject.toml",
+			content: "[build-system]\nrequires = [\"hatchling\"]\n\n[project]\nname = \"app\"\nversion = \"1.2.3\"\n\n[tool.other]\nversion = \"9.9.9\"\n",
+			want:    "[build-system]\nrequires = [\"hatchling\"]\n\n[project]\nname = \"app\"\nversion = \"1.3.0\"\n\n[tool.other]\nversion = \"9.9.9\"\n",
+		},
+		{
+			name:    "poetry",
+			path:    "pyproject.toml",
+			content: "[tool.poetry]\nversion = '1.2.3'\n",
+			want:    "[tool.poetry]\nversion = '1.3.0'\n",
+		},
+		{
+			name:    "cargo",
+			path:    "Cargo.toml",
+			content: "[package]\nname = \"app\"\nversion = \"1.2.3\"\n\n[dependencies]\nserde = { version = \"1.0\" }\n",
+			want:    "[package]\nname = \"app\"\nversion = \"1.3.0\"\n\n[dependencies]\nserde = { version = \"1.0\" }\n",
+		},
+		{
+			name:    "plain",
+			path:    "VERSION",
+			content: "1.2.3\n",
+			want:    "1.3.0\n",
+		},
+		{
+			name:    "go const",
+			path:    "version.go",
+			content: "package version\n\n// Version 是当前版本\nconst Version = \"1.2.3\"\n",
+			want:    "package version\n\n// Version 是当前版本\nconst Version = \"1.3.0\"\n",
+		},
+		{
+			name:    "go const block",
+			path:    "version.go",
+			content: "package version\n\nconst (\n\tName            = \"app\"\n\tVersion string = \"1.2.3\"\n)\n",
+			want:    "package version\n\nconst (\n\tName            = \"app\"\n\tVersion string = \"1.3.0\"\n)\n",
+		},
+		{
+			name:    "regex",
+			path:    "deploy.yml",
+			typ:     VersionFileRegex,
+			pattern: `image: registry/app:(?P<version>\S+)`,
+			content: "image: registry/app:1.2.3\nsidecar: registry/proxy:1.2.3\n",
+			want:    "image: registry/app:1.3.0\nsidecar: registry/proxy:1.2.3\n",
+		},
+		{
+			name:    "marker",
+			path:    "README.md",
+			typ:     VersionFileMarker,
+			marker:  "x-release-version",
+			content: "go install app@v1.2.3 <!-- x-release-version -->\nrequires lib 1.2.3\n",
+			want:    "go install app@v1.3.0 <!-- x-release-version -->\nrequires lib 1.2.3\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewVersionFile(tt.path, tt.typ, tt.pattern, tt.marker)
+			require.NoError(t, err)
+			got, err := f.Update([]byte(tt.content), "1.3.0")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestVersionFileUpdateNotFound(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+	}{
+		{"package.json", "{\"name\": \"app\", \"config\": {\"version\": \"1.0.0\"}}"},
+		{"package.json", "{\"version\": 1}"},
+		{"package.json", "{"},
+		{"Chart.yaml", "name: app\n"},
+		{"pom.xml", "<project><parent><version>1.0.0</version></parent></project>"},
+		{"pyproject.toml", "[project]\ndynamic = [\"version\"]\n[tool.other]\nversion = \"1.0.0\"\n"},
+		{"Cargo.toml", "[package]\nversion.workspace = true\n"},
+		{"main.go", "package main\n\nvar version = \"1.0.0\"\n"},
+	}
+	for _, tt := range tests {
+		f, err := NewVersionFile(tt.path, "", "", "")
+		require.NoError(t, err)
+		_, err = f.Update([]byte(tt.content), "1.3.0")
+		assert.Error(t, err, tt.path)
+	}
+}
@@ -34,8 +34,11 @@ func NewClient(token string, apiURL string, skipSSLVerify bool) (*gitlab.Client,
 		return nil, errors.New("Gitlab user token not set")
 	}
 
+	// 重试由 workflow 负责，创建操作重试前会先检查上一次请求是否已经生效。
+	// 客户端自身不重试，否则创建标签等操作在服务器已经处理但返回 5xx 时会被直接重复提交
 	opts := []gitlab.ClientOptionFunc{
 		gitlab.WithHTTPClient(httpClientWithTimeout(time.Second*90, skipSSLVerify)),
+		gitlab.WithoutRetries(),
 	}
 
 	if len(apiURL) > 0 {
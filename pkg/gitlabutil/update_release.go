@@ -28,7 +28,8 @@ type UpdateReleaseResponse struct {
 // project: 项目路径
 // tagID: 标签 ID
 // description: 新的描述信息
-func UpdateTagDescription(client *gitlab.Client, project string, tagID string, description string) (*UpdateReleaseResponse, *gitlab.Response, error) {
+// options: 请求选项，例如 gitlab.WithContext
+func UpdateTagDescription(client *gitlab.Client, project string, tagID string, description string, options ...gitlab.RequestOptionFunc) (*UpdateReleaseResponse, *gitlab.Response, error) {
 	updateOptions := &UpdateReleaseOptions{
 		ID:          project,
 		TagName:     tagID,
@@ -36,7 +37,7 @@ func UpdateTagDescription(client *gitlab.Client, project string, tagID string, d
 	}
 	updateResp := UpdateReleaseResponse{}
 	u := fmt.Sprintf("projects/%s/repository/tags/%s/release", url.QueryEscape(project), tagID)
-	req, err := client.NewRequest("PUT", u, updateOptions, nil)
+	req, err := client.NewRequest("PUT", u, updateOptions, options)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "add-download make request")
 	}
@@ -98,11 +98,28 @@ func ChangelogEntry(releaseInfo interface{}) (string, error) {
 	return render(releaseInfo, changelogTmpl, funcs)
 }
 
-// BumpMessage renders tag using tmpl
-func BumpMessage(tag string, tmpl string) (string, error) {
-	return render(nil, tmpl, template.FuncMap{
+// BumpData is the data available to the bump commit message template
+type BumpData struct {
+	// Tag is the new tag, e.g. v1.2.3
+	Tag string
+	// Version is the new version without the tag prefix, e.g. 1.2.3
+	Version string
+}
+
+// BumpMessage renders the bump commit message tmpl. The template can use {{tag}},
+// {{.Tag}} and {{.Version}}; any other field is an error instead of "<no value>"
+func BumpMessage(tag, version, tmpl string) (string, error) {
+	t, err := template.New("").Funcs(template.FuncMap{
 		"tag": func() string { return tag },
-	})
+	}).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, BumpData{Tag: tag, Version: version}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
 }
 
 // SetPreReleaseNumber adds pre- and build parts to the next version of releaseData
@@ -124,40 +141,65 @@ func SetPreReleaseNumber(releaseData *semrel.ReleaseData, context *cli.Context)
 	}
 
 	if len(buildTs) > 0 {
-		buildIDs := make([]string, len(buildTs))
-		for i, buildT := range buildTs {
-			buildStr, err := render(nil, buildT, fns)
-			if err != nil {
-				return errors.Wrapf(err, "formatting %s", buildT)
-			}
-			buildID, err := semver.NewBuildVersion(buildStr)
-			if err != nil {
-				return errors.Wrap(err, "build version tmpl")
-			}
-			buildIDs[i] = buildID
+		buildIDs, err := BuildIDs(buildTs, nil, fns)
+		if err != nil {
+			return err
 		}
 		releaseData.NextVersion.Build = buildIDs
 	}
 
 	if len(preTs) > 0 {
-		preIDs := make([]semver.PRVersion, 0)
-		for _, preT := range preTs {
-			fns["seq"] = getVersionsFun(versions, preIDs)
-			preStr, err := render(nil, preT, fns)
-			if err != nil {
-				return errors.Wrap(err, "pre version tmpl")
-			}
-			preID, err := semver.NewPRVersion(preStr)
-			if err != nil {
-				return errors.Wrap(err, "pre version tmpl")
-			}
-			preIDs = append(preIDs, preID)
+		preIDs, err := PreReleaseIDs(preTs, nil, versions, fns)
+		if err != nil {
+			return err
 		}
 		releaseData.NextVersion.Pre = preIDs
 	}
 	return nil
 }
 
+// PreReleaseIDs renders pre-release identifier templates with data and fns.
+// In addition to fns the templates can use {{ seq }}, which returns one more than the highest
+// number following the already rendered identifiers in versions, so every distinct prefix
+// (for example every release channel) is numbered independently.
+func PreReleaseIDs(tmpls []string, data interface{}, versions []semver.Version, fns template.FuncMap) ([]semver.PRVersion, error) {
+	preIDs := make([]semver.PRVersion, 0, len(tmpls))
+	for _, preT := range tmpls {
+		f := template.FuncMap{}
+		for name, fn := range fns {
+			f[name] = fn
+		}
+		f["seq"] = getVersionsFun(versions, preIDs)
+		preStr, err := render(data, preT, f)
+		if err != nil {
+			return nil, errors.Wrap(err, "pre version tmpl")
+		}
+		preID, err := semver.NewPRVersion(preStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "pre version tmpl")
+		}
+		preIDs = append(preIDs, preID)
+	}
+	return preIDs, nil
+}
+
+// BuildIDs renders build metadata templates with data and fns
+func BuildIDs(tmpls []string, data interface{}, fns template.FuncMap) ([]string, error) {
+	buildIDs := make([]string, len(tmpls))
+	for i, buildT := range tmpls {
+		buildStr, err := render(data, buildT, fns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "formatting %s", buildT)
+		}
+		buildID, err := semver.NewBuildVersion(buildStr)
+		if err != nil {
+			return nil, errors.Wrap(err, "build version tmpl")
+		}
+		buildIDs[i] = buildID
+	}
+	return buildIDs, nil
+}
+
 func getMatchingVersions(v semver.Version) ([]semver.Version, error) {
 	versions := make([]semver.Version, 0)
 
@@ -5,7 +5,7 @@ import (
 )
 
 func TestBump(t *testing.T) {
-	tag, err := BumpMessage("t", "tag is {{tag}}")
+	tag, err := BumpMessage("t", "1.0.0", "tag is {{tag}}")
 	if err != nil {
 		t.Error(err)
 	}
@@ -13,3 +13,17 @@ func TestBump(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestBumpVersion(t *testing.T) {
+	msg, err := BumpMessage("v1.2.3", "1.2.3", "chore: release {{.Version}} ({{.Tag}}) [skip ci]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg != "chore: release 1.2.3 (v1.2.3) [skip ci]" {
+		t.Errorf("unexpected message %q", msg)
+	}
+
+	if _, err := BumpMessage("v1.2.3", "1.2.3", "chore: {{.NextVersion}}"); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/fanny7d/semrel-gitlab/pkg/render"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// SetChannel 设置分支 branch 所属的发布渠道。预发布渠道的下一个版本带有预发布版本标识，
+// 例如 1.3.0-beta.2，序号按渠道独立计数
+func (s *GitService) SetChannel(channel domain.Channel, branch string) {
+	s.channel = &channel
+	s.branch = branch
+}
+
+// SetVersionTemplates 设置预发布版本和构建元数据的模板。
+// pre 是预发布渠道在渠道标识之后的标识，为空时使用 {{ seq }}；build 为空时不添加构建元数据
+func (s *GitService) SetVersionTemplates(pre, build []string) {
+	s.preTmpl = pre
+	s.buildTmpl = build
+}
+
+// CurrentBranch 返回本地仓库当前检出的分支，HEAD 不指向分支或仓库还没有提交时返回空字符串
+func (s *GitService) CurrentBranch() (string, error) {
+	repo, err := git.PlainOpen(s.path)
+	if err != nil {
+		return "", errors.Wrap(err, "打开 Git 仓库失败")
+	}
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "获取 HEAD 引用失败")
+	}
+	if !head.Name().IsBranch() {
+		return "", nil
+	}
+	return head.Name().Short(), nil
+}
+
+// applyChannel 按发布渠道为有变更的下一个版本添加预发布版本标识，并按模板添加构建元数据。
+// 模板中的 commitTS 是 HEAD 提交的提交时间 commitTime。
+// Release-As 指定了预发布版本时保持不变
+func (s *GitService) applyChannel(repo *git.Repository, version *domain.Version, commitTime time.Time) error {
+	if version.Level == domain.NoBump || (s.channel == nil && len(s.buildTmpl) == 0) {
+		return nil
+	}
+
+	var data interface{}
+	name := ""
+	if s.channel != nil {
+		data = domain.NewChannelData(*s.channel, s.branch)
+		name = s.channel.Name
+	}
+	fns := template.FuncMap{
+		"env":      os.Getenv,
+		"commitTS": func() time.Time { return commitTime.UTC() },
+	}
+
+	var pre []semver.PRVersion
+	if s.channel != nil && !s.channel.Stable() && len(version.Next.Pre) == 0 {
+		tmpls := append([]string{s.channel.PreRelease}, s.preTmpl...)
+		if len(s.preTmpl) == 0 {
+			tmpls = append(tmpls, "{{ seq }}")
+		}
+		versions, err := s.versionsOf(repo, version.Next)
+		if err != nil {
+			return errors.Wrap(err, "读取预发布版本标签失败")
+		}
+		if pre, err = render.PreReleaseIDs(tmpls, data, versions, fns); err != nil {
+			return errors.Wrapf(err, "生成发布渠道 %s 的预发布版本失败", name)
+		}
+	}
+
+	build, err := render.BuildIDs(s.buildTmpl, data, fns)
+	if err != nil {
+		return errors.Wrap(err, "生成构建元数据失败")
+	}
+	version.SetChannel(name, pre, build)
+	return nil
+}
+
+// versionsOf 返回以 tagPrefix 开头、主版本号、次版本号和修订号与 v 相同的所有版本标签，包括预发布版本
+func (s *GitService) versionsOf(repo *git.Repository, v semver.Version) ([]semver.Version, error) {
+	versions := make([]semver.Version, 0)
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if !strings.HasPrefix(name, s.tagPrefix) {
+			return nil
+		}
+		tv, err := s.scheme.Parse(strings.TrimPrefix(name, s.tagPrefix))
+		if err == nil && tv.Major == v.Major && tv.Minor == v.Minor && tv.Patch == v.Patch {
+			versions = append(versions, tv)
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// markPreReleased 把已经在同一个渠道之前的预发布版本中发布的提交标记为预发布，
+// 例如计算 1.3.0-beta.3 时标记 1.3.0-beta.1 和 1.3.0-beta.2 可以到达的提交，发布说明只列出新的提交
+func (s *GitService) markPreReleased(repo *git.Repository, version *domain.Version, changes []*domain.Commit) error {
+	next := version.Next
+	if len(next.Pre) == 0 || len(changes) == 0 {
+		return nil
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return err
+	}
+	shipped := map[plumbing.Hash]bool{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if !strings.HasPrefix(name, s.tagPrefix) {
+			return nil
+		}
+		v, err := s.scheme.Parse(strings.TrimPrefix(name, s.tagPrefix))
+		if err != nil || len(v.Pre) == 0 || v.Major != next.Major || v.Minor != next.Minor || v.Patch != next.Patch ||
+			v.Pre[0].Compare(next.Pre[0]) != 0 || !v.LT(next) {
+			return nil
+		}
+		hash, err := tagCommit(repo, ref)
+		if err != nil || shipped[hash] {
+			return nil
+		}
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil
+		}
+		return object.NewCommitPreorderIter(commit, shipped, nil).ForEach(func(c *object.Commit) error {
+			shipped[c.Hash] = true
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if shipped[plumbing.NewHash(c.Hash)] {
+			c.SetPreReleased(true)
+		}
+	}
+	return nil
+}
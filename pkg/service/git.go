@@ -1,20 +1,46 @@
 package service
 
 import (
-	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/blang/semver"
 	"github.com/fanny7d/semrel-gitlab/pkg/domain"
 	"github.com/pkg/errors"
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
 // GitService 提供 Git 相关操作
 type GitService struct {
-	patchTypes []string
-	minorTypes []string
-	tagPrefix  string
+	patchTypes  []string
+	minorTypes  []string
+	ignoreTypes []string
+	tagPrefix   string
+	path        string
+	bumpOptions domain.BumpOptions
+	tagger      object.Signature
+	signer      TagSigner
+	parser      domain.CommitParser
+	scheme      domain.VersionScheme
+
+	mergeStrategy domain.MergeStrategy
+	mergeRequests MergeRequestLookup
+	paths         []string
+	releaseAs     string
+	maintenance   *domain.MaintenanceRange
+
+	channel   *domain.Channel
+	branch    string
+	preTmpl   []string
+	buildTmpl []string
+
+	now func() time.Time
 }
 
 // NewGitService 创建一个新的 Git 服务
@@ -23,13 +49,97 @@ func NewGitService(patchTypes, minorTypes []string, tagPrefix string) *GitServic
 		patchTypes: patchTypes,
 		minorTypes: minorTypes,
 		tagPrefix:  tagPrefix,
+		path:       ".",
+		tagger:     object.Signature{Name: "semrel-gitlab", Email: "semrel-gitlab@localhost"},
+		parser:     domain.CommitParserFunc(domain.ParseCommit),
+		scheme:     domain.SemVer{},
+
+		mergeStrategy: domain.MergeTitle,
+		now:           time.Now,
+	}
+}
+
+// SetPaths 设置 monorepo 组件包含的目录或文件，分析时只考虑修改了这些路径的提交。
+// 为空时考虑所有提交
+func (s *GitService) SetPaths(paths []string) {
+	s.paths = paths
+}
+
+// SetIgnoreTypes 设置忽略的提交类型，这些类型的提交不参与版本计算，也不列在发布说明中。
+// 破坏性变更不会被忽略
+func (s *GitService) SetIgnoreTypes(types []string) {
+	s.ignoreTypes = types
+}
+
+// SetParser 设置解析提交消息的解析器，默认按 Conventional Commits 规范解析
+func (s *GitService) SetParser(parser domain.CommitParser) {
+	s.parser = parser
+}
+
+// SetScheme 设置版本号方案，标签中的版本按该方案解析，下一个版本按该方案计算和命名
+func (s *GitService) SetScheme(scheme domain.VersionScheme) {
+	s.scheme = scheme
+}
+
+// SetClock 设置获取当前时间的函数，默认为 time.Now。发布日期是它返回的时间对应的 UTC 日期，日历版本根据发布日期确定周期
+func (s *GitService) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// SetReleaseAs 设置 --release-as 指定的下一个版本，优先于 Release-As 脚注，为空时不指定
+func (s *GitService) SetReleaseAs(version string) {
+	s.releaseAs = version
+}
+
+// SetMaintenance 把分析限制在维护分支的版本范围内：只把范围内的标签作为上一个版本，
+// 下一个版本超出范围时 AnalyzeCommits 返回错误
+func (s *GitService) SetMaintenance(r domain.MaintenanceRange) {
+	s.maintenance = &r
+}
+
+// SetMergeStrategy 设置分析合并提交的方式
+func (s *GitService) SetMergeStrategy(strategy domain.MergeStrategy) {
+	s.mergeStrategy = strategy
+}
+
+// SetMergeRequests 设置合并提交消息中没有符合规范的合并请求标题时，
+// 用于查询合并请求标题的 MergeRequestLookup，为 nil 时不查询
+func (s *GitService) SetMergeRequests(lookup MergeRequestLookup) {
+	s.mergeRequests = lookup
+}
+
+// SetSigner 设置本地创建附注标签时使用的签名器，为 nil 时不签名
+func (s *GitService) SetSigner(signer TagSigner) {
+	s.signer = signer
+}
+
+// SetTagger 设置本地创建附注标签时使用的标签作者，名称或邮箱为空时保持默认值
+func (s *GitService) SetTagger(name, email string) {
+	if name != "" {
+		s.tagger.Name = name
+	}
+	if email != "" {
+		s.tagger.Email = email
 	}
 }
 
-// AnalyzeCommits 分析提交历史并返回发布数据
+// SetBumpOptions 设置计算下一个版本时使用的策略
+func (s *GitService) SetBumpOptions(opts domain.BumpOptions) {
+	s.bumpOptions = opts
+}
+
+// AnalyzeCommits 分析自上一个发布标签以来的提交历史并返回发布数据。
+// 从 HEAD 开始沿每个父分支回溯，遇到第一个与 tagPrefix 匹配的语义化版本标签即停止，
+// 最高的标签版本作为当前版本。
+// 合并提交按合并策略处理：MergeTitle 用合并请求标题代表被合并分支上的提交，
+// 不符合规范的合并提交（例如 "Merge branch 'x' into 'main'"）不作为变更。
+// 设置了组件路径时只分析修改了这些路径的提交。
+// 返回的发布中的 Explanation 记录每个提交的分析结果和没有参与版本计算的提交。
+// 设置了预发布渠道时，下一个版本带有渠道的预发布版本标识，已经在同一个渠道之前的预发布版本中发布的提交被标记为预发布。
+// 设置了维护分支时，上一个版本是范围内最高的标签，下一个版本超出范围时返回错误。
 func (s *GitService) AnalyzeCommits() (*domain.Release, error) {
 	// 打开 Git 仓库
-	repo, err := git.PlainOpen(".")
+	repo, err := git.PlainOpen(s.path)
 	if err != nil {
 		return nil, errors.Wrap(err, "打开 Git 仓库失败")
 	}
@@ -39,75 +149,406 @@ func (s *GitService) AnalyzeCommits() (*domain.Release, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "获取 HEAD 引用失败")
 	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, errors.Wrap(err, "获取 HEAD 提交失败")
+	}
+	return s.analyze(repo, headCommit, nil)
+}
 
-	// 获取提交历史
-	iter, err := repo.Log(&git.LogOptions{From: head.Hash()})
+// analyze 分析 headCommit 之前自上一个发布标签以来的提交。
+// promoted 不为 nil 时下一个版本是预发布版本提升后的正式版本，不使用发布渠道，
+// 所有提交都标记为已经在预发布版本中发布
+func (s *GitService) analyze(repo *git.Repository, headCommit *object.Commit, promoted *Promotion) (*domain.Release, error) {
+	// 读取发布标签
+	tags, err := s.releaseTags(repo)
 	if err != nil {
-		return nil, errors.Wrap(err, "获取提交历史失败")
+		return nil, errors.Wrap(err, "读取标签失败")
+	}
+
+	// 收集未发布的提交
+	commits, current, err := unreleasedCommits(headCommit, tags)
+	if err != nil {
+		return nil, errors.Wrap(err, "分析提交历史失败")
 	}
 
 	// 创建版本对象
-	version := domain.NewVersion(time.Now())
-	release := domain.NewRelease(version, s.tagPrefix)
+	version := domain.NewVersion(s.now().UTC())
+	version.SetScheme(s.scheme)
+	version.SetCurrent(current)
+	version.SetOptions(s.bumpOptions)
+
+	// 合并请求标题代表的合并提交，以及被它们代表的提交
+	messages := map[plumbing.Hash]string{}
+	covered := map[plumbing.Hash]bool{}
+	if s.mergeStrategy == domain.MergeTitle {
+		unreleased := make(map[plumbing.Hash]bool, len(commits))
+		for _, commit := range commits {
+			unreleased[commit.Hash] = true
+		}
+		messages, covered, err = s.mergeRequestMessages(headCommit, unreleased)
+		if err != nil {
+			return nil, errors.Wrap(err, "分析合并提交失败")
+		}
+	}
+
+	// 分析每个提交，记录没有参与版本计算的提交及原因
+	explanation := &domain.Explanation{
+		Commits: make([]domain.ExplainedCommit, 0, len(commits)),
+		Ignored: make([]domain.IgnoredCommit, 0),
+	}
+	for hash, v := range tags {
+		if v.Equals(current) {
+			explanation.BaseTag = s.tagPrefix + version.CurrentString()
+			explanation.BaseCommit = hash.String()
+			break
+		}
+	}
+	if s.maintenance != nil && explanation.BaseTag == "" {
+		return nil, errors.Errorf("维护分支的历史中没有版本范围 %s 内的发布标签", s.maintenance.Bounds())
+	}
+	ignore := func(commit *object.Commit, reason domain.IgnoreReason) {
+		explanation.Ignored = append(explanation.Ignored, domain.IgnoredCommit{
+			Hash:   commit.Hash.String(),
+			Header: strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0]),
+			Reason: reason,
+		})
+	}
+
+	changes := make([]*domain.Commit, 0, len(commits))
+	for _, commit := range commits {
+		if covered[commit.Hash] {
+			ignore(commit, domain.IgnoreCoveredByMerge)
+			continue
+		}
+		if len(s.paths) > 0 {
+			ok, err := touchesPaths(commit, s.paths)
+			if err != nil {
+				return nil, errors.Wrap(err, "分析提交修改的文件失败")
+			}
+			if !ok {
+				ignore(commit, domain.IgnoreOutsidePaths)
+				continue
+			}
+		}
 
-	// 分析每个提交
-	err = iter.ForEach(func(commit *object.Commit) error {
 		// 解析提交消息
-		msg := commit.Message
+		msg, ok := messages[commit.Hash]
+		if !ok {
+			msg = commit.Message
+		}
 		if msg == "" {
-			return nil
+			ignore(commit, domain.IgnoreEmptyMessage)
+			continue
+		}
+		// 不符合规范的合并提交只是合并的记录，变更来自被合并的提交
+		if !ok && commit.NumParents() > 1 && !s.recognized(msg) {
+			ignore(commit, domain.IgnoreUnrecognizedMerge)
+			continue
+		}
+
+		// 按设置的提交约定解析提交消息
+		change := s.parser.Parse(commit.Hash.String(), msg)
+		if !change.Breaking && slices.Contains(s.ignoreTypes, string(change.Type)) {
+			ignore(commit, domain.IgnoreType)
+			continue
+		}
+		change.Author = commit.Author.Name
+		changes = append(changes, change)
+	}
+
+	// 同一个发布中被回滚的提交和回滚提交相互抵消
+	remaining := domain.CancelReverts(changes)
+	active := make(map[*domain.Commit]bool, len(remaining))
+	for _, c := range remaining {
+		active[c] = true
+	}
+	for _, c := range changes {
+		if !active[c] {
+			explanation.Ignored = append(explanation.Ignored, domain.IgnoredCommit{Hash: c.Hash, Header: c.Header, Reason: domain.IgnoreReverted})
+		}
+	}
+	changes = remaining
+
+	// 确定版本升级级别
+	for _, c := range changes {
+		version.Bump(c.DetermineLevel(s.patchTypes, s.minorTypes))
+		explanation.Commits = append(explanation.Commits, c.Explain(s.patchTypes, s.minorTypes))
+	}
+	if promoted != nil {
+		if err := version.SetReleaseAs(promoted.Final, "预发布标签 "+promoted.Tag); err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			c.SetPreReleased(true)
+		}
+	} else if err := s.applyReleaseAs(version, changes); err != nil {
+		return nil, err
+	}
+	if s.maintenance != nil {
+		if err := s.maintenance.Check(version, changes, s.patchTypes, s.minorTypes); err != nil {
+			return nil, err
+		}
+	}
+	if promoted == nil {
+		if err := s.applyChannel(repo, version, headCommit.Committer.When); err != nil {
+			return nil, err
+		}
+		if err := s.markPreReleased(repo, version, changes); err != nil {
+			return nil, errors.Wrap(err, "读取预发布版本标签失败")
+		}
+	}
+	explanation.Decide(version)
+	if s.maintenance != nil {
+		explanation.Decision.Maintenance = s.maintenance.String()
+	}
+
+	// 添加到变更列表
+	release := domain.NewRelease(version, s.tagPrefix)
+	release.Explanation = explanation
+	for _, c := range changes {
+		release.AddChange(c.Category(), c)
+	}
+
+	return release, nil
+}
+
+// applyReleaseAs 把 --release-as 或最新的 Release-As 脚注指定的版本设置为下一个版本。
+// changes 按从新到旧排列，--release-as 优先于脚注，版本号可以带 v 前缀。
+func (s *GitService) applyReleaseAs(version *domain.Version, changes []*domain.Commit) error {
+	value, source := s.releaseAs, "--release-as"
+	if value == "" {
+		for _, c := range changes {
+			if v := c.ReleaseAs(); v != "" {
+				value, source = v, "提交 "+c.Hash[:7]+" 的 Release-As 脚注"
+				break
+			}
 		}
+	}
+	if value == "" {
+		return nil
+	}
 
-		// 创建提交对象
-		c := domain.NewCommit(
-			commit.Hash.String(),
-			domain.CommitType("fix"), // 默认为修复类型
-			"",                       // 暂不支持 scope
-			msg,
-			"",
-			false,
-		)
+	target, err := s.scheme.Parse(strings.TrimPrefix(value, "v"))
+	if err != nil {
+		return errors.Wrapf(err, "%s 指定的版本 %q 无效", source, value)
+	}
+	return version.SetReleaseAs(target, source)
+}
+
+// releaseTags 返回提交哈希到该提交上的正式版本标签的映射。
+// 只考虑以 tagPrefix 开头且剩余部分为语义化版本的标签，预发布版本被忽略。
+// 设置了维护分支时只考虑范围内的版本。
+func (s *GitService) releaseTags(repo *git.Repository) (map[plumbing.Hash]semver.Version, error) {
+	tags := make(map[plumbing.Hash]semver.Version)
 
-		// 确定版本升级级别
-		level := c.DetermineLevel(s.patchTypes, s.minorTypes)
-		version.Bump(level)
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if !strings.HasPrefix(name, s.tagPrefix) {
+			return nil
+		}
+		v, err := s.scheme.Parse(strings.TrimPrefix(name, s.tagPrefix))
+		if err != nil || len(v.Pre) > 0 {
+			return nil
+		}
+		if s.maintenance != nil && !s.maintenance.Contains(v) {
+			return nil
+		}
 
-		// 添加到变更列表
-		category := string(c.Type)
-		if c.Breaking {
-			category = "breaking"
+		// 附注标签指向标签对象，需要解析到其指向的提交
+		hash, err := tagCommit(repo, ref)
+		if err != nil {
+			return nil
 		}
-		release.AddChange(category, c)
 
+		if existing, ok := tags[hash]; !ok || v.GT(existing) {
+			tags[hash] = v
+		}
 		return nil
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "分析提交历史失败")
+		return nil, err
 	}
 
-	return release, nil
+	return tags, nil
 }
 
-// CreateTag 创建 Git 标签
-func (s *GitService) CreateTag(tagName string) error {
+// unreleasedCommits 从 head 开始广度优先遍历提交历史，在带有发布标签的提交处停止，
+// 返回未发布的提交（按提交时间从新到旧）以及遇到的最高发布版本。
+// 已经包含在任一发布标签中的提交会被排除，即使它们可以通过其他父分支到达。
+func unreleasedCommits(head *object.Commit, tags map[plumbing.Hash]semver.Version) ([]*object.Commit, semver.Version, error) {
+	current := semver.Version{}
+	candidates := make([]*object.Commit, 0)
+	bases := make([]*object.Commit, 0)
+
+	visited := map[plumbing.Hash]bool{}
+	queue := []*object.Commit{head}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if visited[c.Hash] {
+			continue
+		}
+		visited[c.Hash] = true
+
+		if v, ok := tags[c.Hash]; ok {
+			if v.GT(current) {
+				current = v
+			}
+			bases = append(bases, c)
+			continue
+		}
+
+		candidates = append(candidates, c)
+		err := c.Parents().ForEach(func(parent *object.Commit) error {
+			queue = append(queue, parent)
+			return nil
+		})
+		if err != nil {
+			return nil, current, err
+		}
+	}
+
+	// 标记所有发布标签可到达的提交
+	released := map[plumbing.Hash]bool{}
+	for _, base := range bases {
+		if released[base.Hash] {
+			continue
+		}
+		iter := object.NewCommitPreorderIter(base, released, nil)
+		err := iter.ForEach(func(c *object.Commit) error {
+			released[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, current, err
+		}
+	}
+
+	commits := make([]*object.Commit, 0, len(candidates))
+	for _, c := range candidates {
+		if !released[c.Hash] {
+			commits = append(commits, c)
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Committer.When.After(commits[j].Committer.When)
+	})
+
+	return commits, current, nil
+}
+
+// touchesPaths 判断提交相对于第一父提交是否修改了 paths 中的文件。
+// 合并提交相对于第一父提交的修改就是被合并分支带来的修改。
+func touchesPaths(c *object.Commit, paths []string) (bool, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return false, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return false, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return false, err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return false, err
+	}
+	for _, change := range changes {
+		if matchPath(change.From.Name, paths) || matchPath(change.To.Name, paths) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchPath 判断文件 name 是否位于 paths 中的某个目录下或者就是其中的文件
+func matchPath(name string, paths []string) bool {
+	if name == "" {
+		return false
+	}
+	for _, p := range paths {
+		p = strings.Trim(path.Clean(p), "/")
+		if p == "." || name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateTag 在本地仓库中 ref 指向的提交上创建附注标签，ref 为空时使用 HEAD。
+// 设置了签名器时创建签名标签。标签只存在于本地仓库，需要用 PushTag 推送到远程仓库。
+func (s *GitService) CreateTag(tagName, message, ref string) error {
 	// 打开 Git 仓库
-	repo, err := git.PlainOpen(".")
+	repo, err := git.PlainOpen(s.path)
 	if err != nil {
 		return errors.Wrap(err, "打开 Git 仓库失败")
 	}
 
-	// 获取 HEAD 引用
-	head, err := repo.Head()
+	// 解析标签指向的提交
+	if ref == "" {
+		ref = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
 	if err != nil {
-		return errors.Wrap(err, "获取 HEAD 引用失败")
+		return errors.Wrapf(err, "在本地仓库中找不到 %s", ref)
 	}
 
-	// 创建标签
-	_, err = repo.CreateTag(tagName, head.Hash(), &git.CreateTagOptions{
-		Message: fmt.Sprintf("Release %s", tagName),
-	})
+	if _, err := repo.Tag(tagName); err == nil {
+		return errors.Errorf("创建标签 %s 失败: %v", tagName, git.ErrTagExists)
+	}
+
+	// 创建标签对象，设置了签名器时对标签签名
+	tag := &object.Tag{
+		Name: tagName,
+		Tagger: object.Signature{
+			Name:  s.tagger.Name,
+			Email: s.tagger.Email,
+			When:  time.Now(),
+		},
+		Message:    strings.TrimSpace(message) + "\n",
+		TargetType: plumbing.CommitObject,
+		Target:     *hash,
+	}
+	if s.signer != nil {
+		payload := &plumbing.MemoryObject{}
+		if err := tag.EncodeWithoutSignature(payload); err != nil {
+			return errors.Wrapf(err, "创建标签 %s 失败", tagName)
+		}
+		reader, err := payload.Reader()
+		if err != nil {
+			return errors.Wrapf(err, "创建标签 %s 失败", tagName)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return errors.Wrapf(err, "创建标签 %s 失败", tagName)
+		}
+		signature, err := s.signer.Sign(data)
+		if err != nil {
+			return errors.Wrapf(err, "为标签 %s 签名失败", tagName)
+		}
+		tag.PGPSignature = signature
+	}
+	obj := repo.Storer.NewEncodedObject()
+	if err := tag.Encode(obj); err != nil {
+		return errors.Wrapf(err, "创建标签 %s 失败", tagName)
+	}
+	tagHash, err := repo.Storer.SetEncodedObject(obj)
 	if err != nil {
-		return errors.Wrap(err, "创建标签失败")
+		return errors.Wrapf(err, "创建标签 %s 失败", tagName)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewTagReferenceName(tagName), tagHash)); err != nil {
+		return errors.Wrapf(err, "创建标签 %s 失败", tagName)
 	}
 
 	return nil
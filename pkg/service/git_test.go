@@ -0,0 +1,426 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// testRepo 是测试用的临时 Git 仓库
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+	when time.Time
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	return &testRepo{t: t, dir: dir, repo: repo, when: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (r *testRepo) signature() *object.Signature {
+	r.when = r.when.Add(time.Minute)
+	return &object.Signature{Name: "test", Email: "test@example.com", When: r.when}
+}
+
+// commit 修改文件并提交，返回提交哈希
+func (r *testRepo) commit(message string) plumbing.Hash {
+	r.t.Helper()
+	return r.commitFile("file.txt", message)
+}
+
+// commitFile 修改仓库中的指定文件并提交，返回提交哈希
+func (r *testRepo) commitFile(name, message string) plumbing.Hash {
+	r.t.Helper()
+	wt, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	file := filepath.Join(r.dir, filepath.FromSlash(name))
+	require.NoError(r.t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(r.t, os.WriteFile(file, []byte(message), 0644))
+	_, err = wt.Add(name)
+	require.NoError(r.t, err)
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: r.signature()})
+	require.NoError(r.t, err)
+	return hash
+}
+
+// merge 创建一个以 HEAD 和 other 为父提交的合并提交
+func (r *testRepo) merge(message string, other plumbing.Hash) plumbing.Hash {
+	r.t.Helper()
+	head, err := r.repo.Head()
+	require.NoError(r.t, err)
+	headCommit, err := r.repo.CommitObject(head.Hash())
+	require.NoError(r.t, err)
+	sig := r.signature()
+	commit := &object.Commit{
+		Author:       *sig,
+		Committer:    *sig,
+		Message:      message,
+		TreeHash:     headCommit.TreeHash,
+		ParentHashes: []plumbing.Hash{head.Hash(), other},
+	}
+	obj := r.repo.Storer.NewEncodedObject()
+	require.NoError(r.t, commit.Encode(obj))
+	hash, err := r.repo.Storer.SetEncodedObject(obj)
+	require.NoError(r.t, err)
+	require.NoError(r.t, r.repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), hash)))
+	return hash
+}
+
+// checkout 切换到指定提交上的新分支
+func (r *testRepo) checkout(branch string, hash plumbing.Hash) {
+	r.t.Helper()
+	wt, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	require.NoError(r.t, wt.Checkout(&git.CheckoutOptions{
+		Hash:   hash,
+		Branch: plumbing.NewBranchReferenceName(branch),
+		Create: true,
+	}))
+}
+
+func (r *testRepo) lightweightTag(name string, hash plumbing.Hash) {
+	r.t.Helper()
+	_, err := r.repo.CreateTag(name, hash, nil)
+	require.NoError(r.t, err)
+}
+
+func (r *testRepo) annotatedTag(name string, hash plumbing.Hash) {
+	r.t.Helper()
+	_, err := r.repo.CreateTag(name, hash, &git.CreateTagOptions{Tagger: r.signature(), Message: name})
+	require.NoError(r.t, err)
+}
+
+func (r *testRepo) service(tagPrefix string) *GitService {
+	s := NewGitService([]string{"fix"}, []string{"feat"}, tagPrefix)
+	s.path = r.dir
+	return s
+}
+
+func domainSubjects(commits []*domain.Commit) []string {
+	rv := make([]string, 0, len(commits))
+	for _, c := range commits {
+		rv = append(rv, c.Subject)
+	}
+	return rv
+}
+
+func TestAnalyzeCommitsWithoutTags(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("fix: first")
+	r.commit("feat: second")
+
+	release, err := r.service("v").AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0", release.Version.Current.String())
+	assert.Len(t, release.Changes["fix"], 1)
+	assert.Len(t, release.Changes["feat"], 1)
+}
+
+func TestAnalyzeCommitsIgnoreTypes(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("fix: bug")
+	r.commit("chore: tidy")
+	r.commit("chore!: drop node 16")
+	r.commit("ci: cache")
+
+	s := r.service("v")
+	s.SetIgnoreTypes([]string{"chore", "ci"})
+	release, err := s.AnalyzeCommits()
+	require.NoError(t, err)
+
+	// 破坏性变更不会被忽略
+	assert.Empty(t, release.Changes["chore"])
+	assert.Empty(t, release.Changes["ci"])
+	assert.Equal(t, []string{"drop node 16"}, domainSubjects(release.Changes["breaking"]))
+	assert.Equal(t, "test", release.Changes["fix"][0].Author)
+	ignored := make([]string, 0)
+	for _, c := range release.Explanation.Ignored {
+		assert.Equal(t, domain.IgnoreType, c.Reason)
+		ignored = append(ignored, c.Header)
+	}
+	assert.ElementsMatch(t, []string{"chore: tidy", "ci: cache"}, ignored)
+}
+
+func TestAnalyzeCommitsStopsAtReleaseTag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: old feature")
+	r.annotatedTag("v1.2.0", r.commit("fix: released fix"))
+	r.commit("fix: unreleased fix")
+	r.commit("chore: housekeeping")
+
+	release, err := r.service("v").AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", release.Version.Current.String())
+	assert.Equal(t, "v"+release.Version.Next.String(), release.TagName)
+	assert.Equal(t, []string{"unreleased fix"}, domainSubjects(release.Changes["fix"]))
+	assert.Empty(t, release.Changes["feat"])
+	assert.Len(t, release.Changes["chore"], 1)
+}
+
+func TestAnalyzeCommitsTagPrefixAndPreRelease(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("release-1.0.0", r.commit("fix: a"))
+	r.lightweightTag("v9.0.0", r.commit("fix: b"))
+	r.lightweightTag("release-1.1.0-rc.1", r.commit("fix: c"))
+	r.commit("fix: d")
+
+	release, err := r.service("release-").AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", release.Version.Current.String())
+	assert.ElementsMatch(t, []string{"b", "c", "d"}, domainSubjects(release.Changes["fix"]))
+}
+
+func TestAnalyzeCommitsMergedBranches(t *testing.T) {
+	r := newTestRepo(t)
+	fork := r.commit("fix: before fork")
+	r.lightweightTag("v1.0.0", r.commit("fix: released on main"))
+	main, err := r.repo.Head()
+	require.NoError(t, err)
+
+	// 分支从 v1.0.0 之前分出，其上的提交未发布
+	r.checkout("feature", fork)
+	feature := r.commit("feat: on feature branch")
+
+	r.checkout("main2", main.Hash())
+	r.commit("fix: after release")
+	r.merge("Merge branch 'feature'", feature)
+
+	release, err := r.service("v").AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", release.Version.Current.String())
+	assert.ElementsMatch(t, []string{"after release"}, domainSubjects(release.Changes["fix"]))
+	assert.ElementsMatch(t, []string{"on feature branch"}, domainSubjects(release.Changes["feat"]))
+	// 不符合规范的合并提交不作为变更
+	assert.Empty(t, release.Changes["other"])
+}
+
+func TestAnalyzeCommitsReverts(t *testing.T) {
+	r := newTestRepo(t)
+	released := r.commit("feat: released feature")
+	r.lightweightTag("v1.0.0", released)
+	feature := r.commit("feat: unreleased feature")
+	r.commit("fix: keep this")
+	r.commit("Revert \"feat: unreleased feature\"\n\nThis reverts commit " + feature.String() + ".")
+	r.commit("Revert \"feat: released feature\"\n\nThis reverts commit " + released.String() + ".")
+
+	release, err := r.service("v").AnalyzeCommits()
+	require.NoError(t, err)
+	// 未发布的功能被回滚后既不出现在发布说明中，也不触发次要版本升级
+	assert.Empty(t, release.Changes["feat"])
+	assert.Equal(t, []string{"feat: released feature"}, domainSubjects(release.Changes["revert"]))
+	assert.Equal(t, []string{"keep this"}, domainSubjects(release.Changes["fix"]))
+	assert.Equal(t, "1.0.1", release.Version.Next.String())
+}
+
+func TestAnalyzeCommitsRevertReleased(t *testing.T) {
+	r := newTestRepo(t)
+	released := r.commit("feat: released feature")
+	r.lightweightTag("v1.0.0", released)
+	r.commit("Revert \"feat: released feature\"\n\nThis reverts commit " + released.String() + ".")
+
+	// 回滚已经发布的变更时即使 revert 不是补丁版本的提交类型也升级补丁版本
+	release, err := r.service("v").AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feat: released feature"}, domainSubjects(release.Changes["revert"]))
+	assert.Equal(t, "1.0.1", release.Version.Next.String())
+}
+
+func TestAnalyzeCommitsConvention(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.0.0", r.commit(":tada: initial release"))
+	r.commit(":sparkles: add login")
+	r.commit("🐛 handle nil response")
+	r.commit("feat: not gitmoji")
+
+	parser, err := domain.NewCommitParser(string(domain.ConventionGitmoji), "", nil)
+	require.NoError(t, err)
+	s := r.service("v")
+	s.SetParser(parser)
+
+	release, err := s.AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"add login"}, domainSubjects(release.Changes["feat"]))
+	assert.Equal(t, []string{"handle nil response"}, domainSubjects(release.Changes["fix"]))
+	assert.Equal(t, []string{"feat: not gitmoji"}, domainSubjects(release.Changes["other"]))
+	assert.Equal(t, "1.1.0", release.Version.Next.String())
+}
+
+func TestAnalyzeCommitsReleaseAs(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.2.0", r.commit("feat: released"))
+	r.commit("chore: plan 2.0\n\nRelease-As: 2.0.0")
+	r.commit("fix: small fix")
+
+	// 提交中的 Release-As 脚注
+	release, err := r.service("v").AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", release.Version.Next.String())
+	assert.Equal(t, domain.BumpMajor, release.Version.Level)
+	assert.Equal(t, "v2.0.0", release.TagName)
+	assert.Contains(t, release.Version.ReleaseAsSource, "Release-As")
+
+	// --release-as 优先于脚注
+	s := r.service("v")
+	s.SetReleaseAs("v3.1.0")
+	release, err = s.AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.0", release.Version.Next.String())
+	assert.Equal(t, "--release-as", release.Version.ReleaseAsSource)
+
+	// 指定的版本必须大于当前版本
+	s.SetReleaseAs("1.1.0")
+	_, err = s.AnalyzeCommits()
+	assert.Error(t, err)
+
+	r.commit("chore: bad footer\n\nRelease-As: next")
+	_, err = r.service("v").AnalyzeCommits()
+	assert.Error(t, err)
+}
+
+func TestAnalyzeCommitsChannels(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.2.0", r.commit("feat: released"))
+	head := r.commit("feat: new feature")
+	r.lightweightTag("v1.3.0-beta.1", head)
+	r.lightweightTag("v1.3.0-beta.2", head)
+	r.lightweightTag("v1.3.0-next.1", head)
+	r.lightweightTag("v1.4.0-beta.7", head)
+
+	tests := []struct {
+		name    string
+		channel domain.Channel
+		branch  string
+		pre     []string
+		build   []string
+		want    string
+	}{
+		{"stable", domain.Channel{Name: "stable"}, "main", nil, nil, "1.3.0"},
+		{"beta", domain.Channel{Name: "beta", PreRelease: "beta"}, "beta", nil, nil, "1.3.0-beta.3"},
+		{"next", domain.Channel{Name: "next", PreRelease: "next"}, "next", nil, nil, "1.3.0-next.2"},
+		{"feature", domain.Channel{Name: "feature", PreRelease: "{{ .BranchSlug }}"}, "feature/Login", nil, nil, "1.3.0-feature-login.1"},
+		{"pre templates", domain.Channel{Name: "beta", PreRelease: "beta"}, "beta", []string{"rc", "{{ seq }}"}, nil, "1.3.0-beta.rc.1"},
+		{"build templates", domain.Channel{Name: "stable"}, "main", nil, []string{"{{ .Channel }}"}, "1.3.0+stable"},
+		{"commit time", domain.Channel{Name: "stable"}, "main", nil, []string{`{{ (commitTS).Format "20060102150405" }}`}, "1.3.0+20240101000200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := r.service("v")
+			s.SetChannel(tt.channel, tt.branch)
+			s.SetVersionTemplates(tt.pre, tt.build)
+			release, err := s.AnalyzeCommits()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, release.Version.Next.String())
+			assert.Equal(t, "v"+tt.want, release.TagName)
+			assert.Equal(t, tt.channel.Name, release.Explanation.Decision.Channel)
+		})
+	}
+
+	// Release-As 指定的预发布版本不被渠道覆盖
+	s := r.service("v")
+	s.SetChannel(domain.Channel{Name: "beta", PreRelease: "beta"}, "beta")
+	s.SetReleaseAs("2.0.0-alpha.1")
+	release, err := s.AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0-alpha.1", release.Version.Next.String())
+}
+
+func TestAnalyzeCommitsMaintenance(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.8.3", r.commit("feat: one"))
+	branchPoint := r.commit("fix: after 1.8.3")
+	r.lightweightTag("v3.0.0", r.commit("feat!: three"))
+
+	// 1.x 分支从 main 分出后，只把 1.x 范围内的标签作为上一个版本
+	r.checkout("1.x", branchPoint)
+	r.commit("fix: hotfix")
+	s := r.service("v")
+	s.SetMaintenance(domain.MaintenanceRange{Major: 1})
+	release, err := s.AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "1.8.3", release.Version.Current.String())
+	assert.Equal(t, "1.8.4", release.Version.Next.String())
+	assert.Equal(t, "1.x", release.Explanation.Decision.Maintenance)
+
+	// 1.8.x 分支不允许次版本升级
+	r.commit("feat: backport")
+	s = r.service("v")
+	s.SetMaintenance(domain.MaintenanceRange{Major: 1, Minor: 8, MinorFixed: true})
+	_, err = s.AnalyzeCommits()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "feat: backport")
+
+	// 范围内没有发布标签
+	s = r.service("v")
+	s.SetMaintenance(domain.MaintenanceRange{Major: 2})
+	_, err = s.AnalyzeCommits()
+	assert.Error(t, err)
+}
+
+func TestAnalyzeCommitsCalVer(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v2020.01.4", r.commit("feat: one"))
+	r.lightweightTag("v3.0.0", r.commit("feat: semver tag"))
+	r.commit("fix: two")
+
+	scheme, err := domain.NewCalVer("YYYY.0M.MICRO")
+	require.NoError(t, err)
+	s := r.service("v")
+	s.SetScheme(scheme)
+	// 本地时间已经是 11 月，UTC 日期仍然是 10 月 31 日
+	s.SetClock(func() time.Time { return time.Date(2026, 11, 1, 1, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60)) })
+	release, err := s.AnalyzeCommits()
+	require.NoError(t, err)
+
+	// 不符合日历版本格式的标签被忽略，进入新的周期后 MICRO 从 0 开始
+	assert.Equal(t, "2020.01.4", release.Version.CurrentString())
+	assert.Equal(t, "2026.10.0", release.Version.NextString())
+	assert.ElementsMatch(t, []string{"semver tag"}, domainSubjects(release.Changes["feat"]))
+	assert.ElementsMatch(t, []string{"two"}, domainSubjects(release.Changes["fix"]))
+}
+
+func TestAnalyzeCommitsComponentPaths(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("api/v1.0.0", r.commitFile("services/api/main.go", "feat: api released"))
+	r.lightweightTag("web@2.0.0", r.commitFile("web/index.html", "feat: web released"))
+	r.commitFile("services/api/handler.go", "fix: api handler")
+	r.commitFile("services/apidocs/readme.md", "feat: not part of api")
+	r.commitFile("libs/common/util.go", "feat: shared helper")
+	r.commitFile("web/app.js", "fix: web button")
+
+	tests := []struct {
+		prefix  string
+		paths   []string
+		current string
+		next    string
+		changes []string
+	}{
+		{"api/v", []string{"services/api/", "libs/common"}, "1.0.0", "1.1.0", []string{"api handler", "shared helper"}},
+		{"web@", []string{"web"}, "2.0.0", "2.0.1", []string{"web button"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			s := r.service(tt.prefix)
+			s.SetPaths(tt.paths)
+			release, err := s.AnalyzeCommits()
+			require.NoError(t, err)
+			assert.Equal(t, tt.current, release.Version.Current.String())
+			assert.Equal(t, tt.next, release.Version.Next.String())
+			assert.Equal(t, tt.prefix+tt.next, release.TagName)
+			changes := append(domainSubjects(release.Changes["feat"]), domainSubjects(release.Changes["fix"])...)
+			assert.ElementsMatch(t, tt.changes, changes)
+		})
+	}
+}
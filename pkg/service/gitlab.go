@@ -169,7 +169,7 @@ func (c *GitLabClient) CreateRelease(projectPath, tagName string, release *domai
 
 	// 创建发布
 	_, _, err := c.client.Releases.CreateRelease(projectPath, &gitlab.CreateReleaseOptions{
-		Name:        gitlab.String(release.Version.Next.String()),
+		Name:        gitlab.String(release.Version.NextString()),
 		TagName:     gitlab.String(tagName),
 		Description: gitlab.String(description),
 	})
@@ -195,10 +195,11 @@ func generateReleaseDescription(release *domain.Release) string {
 	var description strings.Builder
 
 	// 添加版本信息
-	description.WriteString(fmt.Sprintf("# %s\n\n", release.Version.Next.String()))
+	description.WriteString(fmt.Sprintf("# %s\n\n", release.Version.NextString()))
 
 	// 添加变更类型
-	for category, changes := range release.Changes {
+	for _, category := range release.Categories() {
+		changes := release.ListedChanges(category)
 		if len(changes) == 0 {
 			continue
 		}
@@ -238,6 +239,8 @@ func getCategoryName(category string) string {
 		return "测试相关"
 	case "chore":
 		return "构建过程或辅助工具的变动"
+	case "revert":
+		return "回滚"
 	case "breaking":
 		return "破坏性变更"
 	default:
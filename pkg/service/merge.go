@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// mergeRequestPattern 匹配 GitLab 合并提交消息末尾的 "See merge request group/project!42"
+var mergeRequestPattern = regexp.MustCompile(`(?m)^See merge request (\S+)!(\d+)\s*$`)
+
+// mergeRequestTimeout 是一次分析中查询所有合并请求标题的总时间限制
+const mergeRequestTimeout = 30 * time.Second
+
+// MergeRequestLookup 查找合并提交对应的合并请求标题
+type MergeRequestLookup interface {
+	// MergeRequestTitle 返回合并请求的标题。project 和 iid 来自合并提交消息，
+	// 消息中没有合并请求编号时 iid 为 0，需要根据提交哈希 sha 查找。
+	// 找不到合并请求时返回空字符串。ctx 超时或被取消时应当中断查询。
+	MergeRequestTitle(ctx context.Context, project string, iid int, sha string) (string, error)
+}
+
+// GitLabMergeRequests 通过 GitLab API 查找合并请求
+type GitLabMergeRequests struct {
+	client  *gitlab.Client
+	project string
+}
+
+// NewGitLabMergeRequests 创建通过 GitLab API 查找 project 中合并请求的 MergeRequestLookup
+func NewGitLabMergeRequests(client *gitlab.Client, project string) *GitLabMergeRequests {
+	return &GitLabMergeRequests{client: client, project: project}
+}
+
+// MergeRequestTitle 实现 MergeRequestLookup 接口
+func (m *GitLabMergeRequests) MergeRequestTitle(ctx context.Context, project string, iid int, sha string) (string, error) {
+	if project == "" {
+		project = m.project
+	}
+	if iid > 0 {
+		mr, _, err := m.client.MergeRequests.GetMergeRequest(project, iid, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return "", errors.Wrapf(err, "获取合并请求 %s!%d 失败", project, iid)
+		}
+		return mr.Title, nil
+	}
+
+	mrs, _, err := m.client.Commits.ListMergeRequestsByCommit(project, sha, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", errors.Wrapf(err, "查找提交 %s 的合并请求失败", sha)
+	}
+	for _, mr := range mrs {
+		if mr.MergeCommitSHA == sha || mr.SquashCommitSHA == sha {
+			return mr.Title, nil
+		}
+	}
+	return "", nil
+}
+
+// mergeMessage 返回代表合并提交的提交消息，找不到符合提交约定的消息时返回 false。
+// 依次使用合并提交的标题行、GitLab 合并提交消息中的合并请求标题和通过 API 查询到的合并请求标题。
+// 找不到时变更来自被合并的提交。
+func (s *GitService) mergeMessage(ctx context.Context, c *object.Commit) (string, bool) {
+	if s.recognized(c.Message) {
+		return c.Message, true
+	}
+
+	// GitLab 的合并提交消息: "Merge branch 'x' into 'main'"、合并请求标题、描述和
+	// "See merge request group/project!42"，各部分之间以空行分隔
+	message := strings.ReplaceAll(strings.TrimSpace(c.Message), "\r\n", "\n")
+	match := mergeRequestPattern.FindStringSubmatch(message)
+	description := strings.TrimSpace(mergeRequestPattern.ReplaceAllString(message, ""))
+	if i := strings.Index(description, "\n\n"); i >= 0 {
+		description = strings.TrimSpace(description[i+2:])
+		if s.recognized(description) {
+			return description, true
+		}
+	}
+
+	return s.lookupTitle(ctx, c, match)
+}
+
+// squashMessage 返回代表 squash 提交的合并请求标题，找不到符合提交约定的标题时返回 false。
+// 提交消息本身符合提交约定时不查询，按提交消息分析
+func (s *GitService) squashMessage(ctx context.Context, c *object.Commit) (string, bool) {
+	if s.recognized(c.Message) {
+		return "", false
+	}
+	message := strings.ReplaceAll(strings.TrimSpace(c.Message), "\r\n", "\n")
+	return s.lookupTitle(ctx, c, mergeRequestPattern.FindStringSubmatch(message))
+}
+
+// lookupTitle 通过 MergeRequestLookup 查询提交 c 对应的合并请求标题，match 是提交消息中
+// "See merge request" 的匹配结果，为 nil 时按提交哈希查找。
+// 查询失败（例如没有权限或者合并请求已被删除）时打印警告，按找不到合并请求标题处理
+func (s *GitService) lookupTitle(ctx context.Context, c *object.Commit, match []string) (string, bool) {
+	if s.mergeRequests == nil {
+		return "", false
+	}
+	project, iid := "", 0
+	if match != nil {
+		project = match[1]
+		iid, _ = strconv.Atoi(match[2])
+	}
+	title, err := s.mergeRequests.MergeRequestTitle(ctx, project, iid, c.Hash.String())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 查询提交 %s 的合并请求标题失败，按提交本身分析: %v\n", c.Hash.String()[:7], err)
+		return "", false
+	}
+	if !s.recognized(title) {
+		return "", false
+	}
+	return title, true
+}
+
+// mergeRequestMessages 沿 head 的第一父提交遍历未发布的主线提交，返回可以用合并请求标题代表的
+// 合并提交和 squash 提交及其消息，以及被这些合并提交代表、不再单独分析的提交。
+// 主线上消息不符合提交约定的单父提交可能是 squash 合并的结果，通过 MergeRequestLookup 查询合并请求标题。
+// 查询合并请求标题的总时间不超过 mergeRequestTimeout
+func (s *GitService) mergeRequestMessages(head *object.Commit, unreleased map[plumbing.Hash]bool) (map[plumbing.Hash]string, map[plumbing.Hash]bool, error) {
+	messages := make(map[plumbing.Hash]string)
+	covered := make(map[plumbing.Hash]bool)
+	ctx, cancel := context.WithTimeout(context.Background(), mergeRequestTimeout)
+	defer cancel()
+
+	for c := head; c != nil && unreleased[c.Hash]; {
+		if c.NumParents() > 1 {
+			if message, ok := s.mergeMessage(ctx, c); ok {
+				messages[c.Hash] = message
+				merged, err := mergedCommits(c, unreleased)
+				if err != nil {
+					return nil, nil, err
+				}
+				for hash := range merged {
+					covered[hash] = true
+				}
+			}
+		} else if message, ok := s.squashMessage(ctx, c); ok {
+			messages[c.Hash] = message
+		}
+
+		if c.NumParents() == 0 {
+			break
+		}
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, nil, err
+		}
+		c = parent
+	}
+
+	return messages, covered, nil
+}
+
+// mergedCommits 返回只能通过合并提交的第二个及之后的父提交到达的未发布提交。
+// 同时可以通过第一父提交到达的提交已经在主线上，不属于被合并的分支。
+func mergedCommits(merge *object.Commit, unreleased map[plumbing.Hash]bool) (map[plumbing.Hash]bool, error) {
+	parents := make([]*object.Commit, 0, merge.NumParents())
+	err := merge.Parents().ForEach(func(parent *object.Commit) error {
+		parents = append(parents, parent)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mainline, err := reachable(parents[:1], unreleased, nil)
+	if err != nil {
+		return nil, err
+	}
+	return reachable(parents[1:], unreleased, mainline)
+}
+
+// reachable 返回从 start 出发只经过 within 中的提交能够到达的提交，exclude 中的提交不被访问
+func reachable(start []*object.Commit, within, exclude map[plumbing.Hash]bool) (map[plumbing.Hash]bool, error) {
+	visited := make(map[plumbing.Hash]bool)
+	queue := append([]*object.Commit{}, start...)
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if visited[c.Hash] || !within[c.Hash] || exclude[c.Hash] {
+			continue
+		}
+		visited[c.Hash] = true
+		err := c.Parents().ForEach(func(parent *object.Commit) error {
+			queue = append(queue, parent)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return visited, nil
+}
+
+// recognized 判断提交消息的标题行是否符合设置的提交约定
+func (s *GitService) recognized(message string) bool {
+	return strings.TrimSpace(message) != "" && s.parser.Parse("", message).Type != ""
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// fakeMergeRequests 是返回固定标题的 MergeRequestLookup
+type fakeMergeRequests struct {
+	title   string
+	err     error
+	project string
+	iid     int
+	sha     string
+	// deadline 记录查询时 ctx 是否有截止时间
+	deadline bool
+}
+
+func (f *fakeMergeRequests) MergeRequestTitle(ctx context.Context, project string, iid int, sha string) (string, error) {
+	f.project, f.iid, f.sha = project, iid, sha
+	_, f.deadline = ctx.Deadline()
+	return f.title, f.err
+}
+
+// mergeRequestRepo 创建一个在 v1.0.0 之后通过合并请求合并了 feature 分支的仓库，
+// 返回合并提交的哈希
+func mergeRequestRepo(t *testing.T, message string) (*testRepo, plumbing.Hash) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.0.0", r.commit("feat: released"))
+	main, err := r.repo.Head()
+	require.NoError(t, err)
+
+	r.checkout("feature", main.Hash())
+	feature := r.commit("fix: typo")
+
+	r.checkout("main2", main.Hash())
+	r.commit("docs: readme")
+	return r, r.merge(message, feature)
+}
+
+func TestAnalyzeCommitsMergeStrategy(t *testing.T) {
+	gitlabMessage := "Merge branch 'feature' into 'main'\n\nfeat(auth): add login\n\nCloses #12\n\nSee merge request group/project!3"
+
+	tests := []struct {
+		name     string
+		message  string
+		strategy domain.MergeStrategy
+		lookup   *fakeMergeRequests
+		feat     []string
+		fix      []string
+	}{
+		{"title from merge message", gitlabMessage, domain.MergeTitle, nil, []string{"add login"}, nil},
+		{"conventional merge header", "feat: merged feature", domain.MergeTitle, nil, []string{"merged feature"}, nil},
+		{"branch", gitlabMessage, domain.MergeBranch, nil, nil, []string{"typo"}},
+		{"title without merge request", "Merge branch 'feature'", domain.MergeTitle, nil, nil, []string{"typo"}},
+		{"title from lookup", "Merge branch 'feature' into 'main'\n\nAdd login\n\nSee merge request group/project!3", domain.MergeTitle, &fakeMergeRequests{title: "feat: add login"}, []string{"add login"}, nil},
+		{"non-conventional lookup", "Merge branch 'feature'", domain.MergeTitle, &fakeMergeRequests{title: "Add login"}, nil, []string{"typo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := mergeRequestRepo(t, tt.message)
+			s := r.service("v")
+			s.SetMergeStrategy(tt.strategy)
+			if tt.lookup != nil {
+				s.SetMergeRequests(tt.lookup)
+			}
+
+			release, err := s.AnalyzeCommits()
+			require.NoError(t, err)
+			assert.Equal(t, tt.feat, nilIfEmpty(domainSubjects(release.Changes["feat"])))
+			assert.Equal(t, tt.fix, nilIfEmpty(domainSubjects(release.Changes["fix"])))
+			assert.Equal(t, []string{"readme"}, domainSubjects(release.Changes["docs"]))
+			assert.Empty(t, release.Changes["other"])
+		})
+	}
+}
+
+func TestAnalyzeCommitsMergeRequestLookup(t *testing.T) {
+	r, merge := mergeRequestRepo(t, "Merge branch 'feature' into 'main'\n\nSee merge request group/project!7")
+	lookup := &fakeMergeRequests{title: "feat: from api"}
+	s := r.service("v")
+	s.SetMergeRequests(lookup)
+
+	release, err := s.AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"from api"}, domainSubjects(release.Changes["feat"]))
+	assert.Equal(t, merge.String(), release.Changes["feat"][0].Hash)
+	assert.Equal(t, "group/project", lookup.project)
+	assert.Equal(t, 7, lookup.iid)
+	assert.Equal(t, merge.String(), lookup.sha)
+	assert.True(t, lookup.deadline)
+
+	// 查询失败时按被合并的提交分析
+	lookup.err = errors.New("403 Forbidden")
+	release, err = s.AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Empty(t, release.Changes["feat"])
+	assert.Equal(t, []string{"typo"}, domainSubjects(release.Changes["fix"]))
+	assert.Equal(t, []string{"readme"}, domainSubjects(release.Changes["docs"]))
+}
+
+func TestAnalyzeCommitsSquashCommit(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.0.0", r.commit("feat: released"))
+	// squash 合并只有一个父提交，提交消息默认是源分支第一个提交的消息
+	squash := r.commit("Add login\n\nSee merge request group/project!5")
+	r.commit("fix: typo")
+
+	tests := []struct {
+		name     string
+		strategy domain.MergeStrategy
+		lookup   *fakeMergeRequests
+		feat     []string
+		other    []string
+	}{
+		{"title from lookup", domain.MergeTitle, &fakeMergeRequests{title: "feat: add login"}, []string{"add login"}, nil},
+		{"non-conventional lookup", domain.MergeTitle, &fakeMergeRequests{title: "Add login"}, nil, []string{"Add login"}},
+		{"lookup error", domain.MergeTitle, &fakeMergeRequests{err: errors.New("403 Forbidden")}, nil, []string{"Add login"}},
+		{"branch", domain.MergeBranch, &fakeMergeRequests{title: "feat: add login"}, nil, []string{"Add login"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := r.service("v")
+			s.SetMergeStrategy(tt.strategy)
+			s.SetMergeRequests(tt.lookup)
+
+			release, err := s.AnalyzeCommits()
+			require.NoError(t, err)
+			assert.Equal(t, tt.feat, nilIfEmpty(domainSubjects(release.Changes["feat"])))
+			assert.Equal(t, []string{"typo"}, domainSubjects(release.Changes["fix"]))
+			assert.Equal(t, tt.other, nilIfEmpty(domainSubjects(release.Changes["other"])))
+			if tt.strategy == domain.MergeTitle {
+				assert.Equal(t, "group/project", tt.lookup.project)
+				assert.Equal(t, 5, tt.lookup.iid)
+				assert.Equal(t, squash.String(), tt.lookup.sha)
+			} else {
+				assert.Empty(t, tt.lookup.sha)
+			}
+		})
+	}
+}
+
+func TestAnalyzeCommitsMergeWithoutDoubleCounting(t *testing.T) {
+	for _, strategy := range []domain.MergeStrategy{domain.MergeTitle, domain.MergeBranch} {
+		t.Run(string(strategy), func(t *testing.T) {
+			r := newTestRepo(t)
+			r.lightweightTag("v1.0.0", r.commit("feat: released"))
+			base, err := r.repo.Head()
+			require.NoError(t, err)
+
+			// main 上的修复先被合并到 feature 分支，feature 再合并回 main，
+			// 修复可以通过合并提交的两个父提交到达
+			onMain := r.commit("fix: on main")
+			r.checkout("feature", base.Hash())
+			r.commit("feat: on feature")
+			r.merge("Merge branch 'main' into 'feature'", onMain)
+			feature, err := r.repo.Head()
+			require.NoError(t, err)
+			r.checkout("main2", onMain)
+			r.merge("feat: feature\n\nSee merge request group/project!4", feature.Hash())
+
+			s := r.service("v")
+			s.SetMergeStrategy(strategy)
+			release, err := s.AnalyzeCommits()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"on main"}, domainSubjects(release.Changes["fix"]))
+			if strategy == domain.MergeTitle {
+				assert.Equal(t, []string{"feature"}, domainSubjects(release.Changes["feat"]))
+			} else {
+				assert.ElementsMatch(t, []string{"feature", "on feature"}, domainSubjects(release.Changes["feat"]))
+			}
+			assert.Empty(t, release.Changes["other"])
+		})
+	}
+}
+
+func nilIfEmpty(s []string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+func TestAnalyzeCommitsExplanation(t *testing.T) {
+	r, _ := mergeRequestRepo(t, "Merge branch 'feature' into 'main'")
+	feature := r.commit("feat: short lived")
+	r.commit("Revert \"feat: short lived\"\n\nThis reverts commit " + feature.String() + ".")
+
+	release, err := r.service("v").AnalyzeCommits()
+	require.NoError(t, err)
+	e := release.Explanation
+	require.NotNil(t, e)
+	assert.Equal(t, "v1.0.0", e.BaseTag)
+
+	analyzed := make([]string, 0)
+	for _, c := range e.Commits {
+		analyzed = append(analyzed, c.Header+" "+c.Level.String())
+	}
+	assert.ElementsMatch(t, []string{"fix: typo patch", "docs: readme none"}, analyzed)
+
+	ignored := make(map[string]domain.IgnoreReason)
+	for _, c := range e.Ignored {
+		ignored[c.Header] = c.Reason
+	}
+	assert.Equal(t, map[string]domain.IgnoreReason{
+		"Merge branch 'feature' into 'main'": domain.IgnoreUnrecognizedMerge,
+		"feat: short lived":                  domain.IgnoreReverted,
+		"Revert \"feat: short lived\"":       domain.IgnoreReverted,
+	}, ignored)
+
+	assert.Equal(t, domain.BumpPatch, e.Decision.CommitLevel)
+	assert.Equal(t, "1.0.1", e.Decision.Next)
+	assert.Empty(t, e.Decision.Adjustments)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// Promotion 是提升为正式版本的预发布版本
+type Promotion struct {
+	// Tag 是预发布版本标签
+	Tag string
+	// Final 是去掉预发布版本标识和构建元数据后的正式版本
+	Final semver.Version
+	// Commit 是预发布版本标签指向的提交，正式版本标签创建在该提交上
+	Commit string
+}
+
+// Promote 把预发布版本标签 tagName 提升为正式版本，tagName 为空时使用 HEAD 上最高的预发布版本标签。
+// 下一个版本是去掉预发布版本标识和构建元数据的版本，发布包含自上一个正式版本以来的所有提交，
+// 这些提交都标记为已经在预发布版本中发布
+func (s *GitService) Promote(tagName string) (*domain.Release, Promotion, error) {
+	repo, err := git.PlainOpen(s.path)
+	if err != nil {
+		return nil, Promotion{}, errors.Wrap(err, "打开 Git 仓库失败")
+	}
+	if tagName == "" {
+		if tagName, err = s.headPreReleaseTag(repo); err != nil {
+			return nil, Promotion{}, err
+		}
+	}
+
+	if !strings.HasPrefix(tagName, s.tagPrefix) {
+		return nil, Promotion{}, errors.Errorf("标签 %s 没有使用标签前缀 %s", tagName, s.tagPrefix)
+	}
+	pre, err := s.scheme.Parse(strings.TrimPrefix(tagName, s.tagPrefix))
+	if err != nil {
+		return nil, Promotion{}, errors.Wrapf(err, "标签 %s 不是语义化版本", tagName)
+	}
+	if len(pre.Pre) == 0 {
+		return nil, Promotion{}, errors.Errorf("标签 %s 不是预发布版本", tagName)
+	}
+	final := semver.Version{Major: pre.Major, Minor: pre.Minor, Patch: pre.Patch}
+	if _, err := repo.Tag(s.tagPrefix + s.scheme.Format(final)); err == nil {
+		return nil, Promotion{}, errors.Errorf("正式版本标签 %s%s 已经存在", s.tagPrefix, s.scheme.Format(final))
+	}
+
+	ref, err := repo.Tag(tagName)
+	if err != nil {
+		return nil, Promotion{}, errors.Wrapf(err, "读取预发布版本标签 %s 失败", tagName)
+	}
+	hash, err := tagCommit(repo, ref)
+	if err != nil {
+		return nil, Promotion{}, errors.Wrapf(err, "解析预发布版本标签 %s 失败", tagName)
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, Promotion{}, errors.Wrapf(err, "获取预发布版本标签 %s 的提交失败", tagName)
+	}
+
+	promotion := Promotion{Tag: tagName, Final: final, Commit: hash.String()}
+	release, err := s.analyze(repo, commit, &promotion)
+	if err != nil {
+		return nil, Promotion{}, err
+	}
+	return release, promotion, nil
+}
+
+// headPreReleaseTag 返回 HEAD 上版本最高的预发布版本标签
+func (s *GitService) headPreReleaseTag(repo *git.Repository) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "获取 HEAD 引用失败")
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return "", errors.Wrap(err, "读取标签失败")
+	}
+
+	name, highest := "", semver.Version{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tag := ref.Name().Short()
+		if !strings.HasPrefix(tag, s.tagPrefix) {
+			return nil
+		}
+		v, err := s.scheme.Parse(strings.TrimPrefix(tag, s.tagPrefix))
+		if err != nil || len(v.Pre) == 0 {
+			return nil
+		}
+		if hash, err := tagCommit(repo, ref); err != nil || hash != head.Hash() {
+			return nil
+		}
+		if name == "" || v.GT(highest) {
+			name, highest = tag, v
+		}
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "读取标签失败")
+	}
+	if name == "" {
+		return "", errors.New("HEAD 上没有预发布版本标签，请指定要提升的标签")
+	}
+	return name, nil
+}
+
+// tagCommit 返回标签指向的提交，附注标签解析到其指向的提交
+func tagCommit(repo *git.Repository, ref *plumbing.Reference) (plumbing.Hash, error) {
+	tag, err := repo.TagObject(ref.Hash())
+	if err == plumbing.ErrObjectNotFound {
+		return ref.Hash(), nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	commit, err := tag.Commit()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return commit.Hash, nil
+}
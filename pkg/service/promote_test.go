@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromote(t *testing.T) {
+	r := newTestRepo(t)
+	r.annotatedTag("v1.3.2", r.commit("fix: released"))
+	r.annotatedTag("v1.4.0-rc.1", r.commit("feat: login"))
+	r.commit("fix: qa finding")
+	rc3 := r.commit("fix: another finding")
+	r.annotatedTag("v1.4.0-rc.2", rc3)
+	r.lightweightTag("v1.4.0-rc.3", rc3)
+	r.commit("feat: not in any rc")
+
+	release, promotion, err := r.service("v").Promote("v1.4.0-rc.3")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.4.0-rc.3", promotion.Tag)
+	assert.Equal(t, rc3.String(), promotion.Commit)
+	assert.Equal(t, "1.3.2", release.Version.Current.String())
+	assert.Equal(t, "1.4.0", release.Version.Next.String())
+	assert.Equal(t, "v1.4.0", release.TagName)
+	assert.True(t, release.HasContent())
+
+	// 发布包含所有预发布版本中的提交，不包含之后的提交
+	assert.Equal(t, []string{"login"}, domainSubjects(release.ListedChanges("feat")))
+	assert.ElementsMatch(t, []string{"qa finding", "another finding"}, domainSubjects(release.ListedChanges("fix")))
+	for _, c := range release.Changes["fix"] {
+		assert.True(t, c.IsPreReleased())
+	}
+}
+
+func TestPromoteHeadTag(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.0.0", r.commit("feat: first"))
+	head := r.commit("feat: second")
+	r.lightweightTag("v1.1.0-beta.1", head)
+	r.lightweightTag("v1.1.0-beta.2", head)
+
+	// 没有指定标签时使用 HEAD 上版本最高的预发布版本标签
+	_, promotion, err := r.service("v").Promote("")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0-beta.2", promotion.Tag)
+	assert.Equal(t, "1.1.0", promotion.Final.String())
+
+	tests := []struct {
+		name string
+		tag  string
+	}{
+		{"final version", "v1.0.0"},
+		{"missing tag", "v1.1.0-beta.9"},
+		{"other prefix", "api/v1.1.0-beta.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := r.service("v").Promote(tt.tag)
+			assert.Error(t, err)
+		})
+	}
+
+	// 正式版本标签已经存在
+	r.lightweightTag("v1.1.0", head)
+	_, _, err = r.service("v").Promote("v1.1.0-beta.2")
+	assert.Error(t, err)
+
+	// HEAD 上没有预发布版本标签
+	r.commit("fix: third")
+	_, _, err = r.service("v").Promote("")
+	assert.Error(t, err)
+}
+
+func TestAnalyzeCommitsMarksPreReleased(t *testing.T) {
+	r := newTestRepo(t)
+	r.lightweightTag("v1.0.0", r.commit("feat: first"))
+	shipped := r.commit("feat: in beta.1")
+	r.lightweightTag("v1.1.0-beta.1", shipped)
+	r.lightweightTag("v1.1.0-next.1", r.commit("fix: in next.1"))
+	r.commit("fix: new")
+
+	s := r.service("v")
+	s.SetChannel(domain.Channel{Name: "beta", PreRelease: "beta"}, "beta")
+	release, err := s.AnalyzeCommits()
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0-beta.2", release.Version.Next.String())
+	assert.Empty(t, release.ListedChanges("feat"))
+	// 其他渠道的预发布版本中的提交仍然列出
+	assert.ElementsMatch(t, []string{"in next.1", "new"}, domainSubjects(release.ListedChanges("fix")))
+	assert.True(t, release.HasContent())
+}
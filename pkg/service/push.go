@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// PushOptions 推送标签时使用的远程仓库和凭据
+type PushOptions struct {
+	// RemoteURL 是远程仓库地址，为空时使用本地仓库配置的 origin
+	RemoteURL string
+	// Username 和 Password 是 HTTPS 认证使用的凭据，为空时不认证。
+	// GitLab 的访问令牌使用 oauth2 作为用户名，CI_JOB_TOKEN 使用 gitlab-ci-token
+	Username string
+	Password string
+}
+
+func (o PushOptions) auth() transport.AuthMethod {
+	if o.Username == "" && o.Password == "" {
+		return nil
+	}
+	return &http.BasicAuth{Username: o.Username, Password: o.Password}
+}
+
+// remote 返回推送使用的远程仓库
+func (o PushOptions) remote(repo *git.Repository) (*git.Remote, error) {
+	if o.RemoteURL == "" {
+		remote, err := repo.Remote(git.DefaultRemoteName)
+		if err != nil {
+			return nil, errors.Wrap(err, "获取远程仓库 origin 失败")
+		}
+		return remote, nil
+	}
+	return git.NewRemote(repo.Storer, &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{o.RemoteURL},
+	}), nil
+}
+
+// PushTag 在 ref 指向的提交上创建附注标签并推送到远程仓库，然后确认远程仓库中的标签
+// 与本地标签一致。推送或确认失败时删除本地标签，使本地仓库保持原样。
+func (s *GitService) PushTag(ctx context.Context, tagName, message, ref string, opts PushOptions) error {
+	if err := s.CreateTag(tagName, message, ref); err != nil {
+		return err
+	}
+	if err := s.pushTag(ctx, tagName, opts); err != nil {
+		if deleteErr := s.deleteLocalTag(tagName); deleteErr != nil {
+			return fmt.Errorf("%v (删除本地标签失败: %v)", err, deleteErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *GitService) pushTag(ctx context.Context, tagName string, opts PushOptions) error {
+	repo, err := git.PlainOpen(s.path)
+	if err != nil {
+		return errors.Wrap(err, "打开 Git 仓库失败")
+	}
+	local, err := repo.Tag(tagName)
+	if err != nil {
+		return errors.Wrapf(err, "获取本地标签 %s 失败", tagName)
+	}
+	remote, err := opts.remote(repo)
+	if err != nil {
+		return err
+	}
+
+	refSpec := config.RefSpec(fmt.Sprintf("%s:%s", local.Name(), local.Name()))
+	err = remote.PushContext(ctx, &git.PushOptions{
+		RemoteName: remote.Config().Name,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       opts.auth(),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.Wrapf(err, "推送标签 %s 失败", tagName)
+	}
+
+	// 确认远程仓库中的标签指向同一个标签对象
+	refs, err := remote.List(&git.ListOptions{Auth: opts.auth()})
+	if err != nil {
+		return errors.Wrapf(err, "确认标签 %s 失败", tagName)
+	}
+	for _, r := range refs {
+		if r.Name() == local.Name() {
+			if r.Hash() != local.Hash() {
+				return errors.Errorf("远程仓库中的标签 %s 指向 %s，而不是 %s", tagName, r.Hash(), local.Hash())
+			}
+			return nil
+		}
+	}
+	return errors.Errorf("推送后远程仓库中没有标签 %s", tagName)
+}
+
+// DeleteTag 删除远程仓库和本地仓库中的标签
+func (s *GitService) DeleteTag(ctx context.Context, tagName string, opts PushOptions) error {
+	repo, err := git.PlainOpen(s.path)
+	if err != nil {
+		return errors.Wrap(err, "打开 Git 仓库失败")
+	}
+	remote, err := opts.remote(repo)
+	if err != nil {
+		return err
+	}
+	refSpec := config.RefSpec(":" + plumbing.NewTagReferenceName(tagName).String())
+	err = remote.PushContext(ctx, &git.PushOptions{
+		RemoteName: remote.Config().Name,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       opts.auth(),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return errors.Wrapf(err, "删除远程标签 %s 失败", tagName)
+	}
+	return s.deleteLocalTag(tagName)
+}
+
+func (s *GitService) deleteLocalTag(tagName string) error {
+	repo, err := git.PlainOpen(s.path)
+	if err != nil {
+		return errors.Wrap(err, "打开 Git 仓库失败")
+	}
+	if err := repo.DeleteTag(tagName); err != nil && err != git.ErrTagNotFound {
+		return errors.Wrapf(err, "删除本地标签 %s 失败", tagName)
+	}
+	return nil
+}
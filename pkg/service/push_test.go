@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// withRemote 创建一个空的裸仓库作为 origin
+func (r *testRepo) withRemote() *git.Repository {
+	r.t.Helper()
+	dir := r.t.TempDir()
+	remote, err := git.PlainInit(dir, true)
+	require.NoError(r.t, err)
+	_, err = r.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}})
+	require.NoError(r.t, err)
+	return remote
+}
+
+func TestCreateTag(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("feat: first")
+	r.commit("fix: second")
+
+	s := r.service("v")
+	s.SetTagger("CI", "ci@example.com")
+	require.NoError(t, s.CreateTag("v1.0.0", "Release v1.0.0", first.String()))
+
+	ref, err := r.repo.Tag("v1.0.0")
+	require.NoError(t, err)
+	tag, err := r.repo.TagObject(ref.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, first, tag.Target)
+	assert.Equal(t, "CI", tag.Tagger.Name)
+	assert.Equal(t, "Release v1.0.0\n", tag.Message)
+
+	assert.Error(t, s.CreateTag("v1.0.0", "again", ""), "tag already exists")
+	assert.Error(t, s.CreateTag("v2.0.0", "unknown ref", "0123456789abcdef0123456789abcdef01234567"))
+}
+
+func TestPushTag(t *testing.T) {
+	r := newTestRepo(t)
+	head := r.commit("feat: first")
+	remote := r.withRemote()
+	s := r.service("v")
+	ctx := context.Background()
+
+	require.NoError(t, s.PushTag(ctx, "v1.0.0", "Release v1.0.0", "", PushOptions{}))
+	local, err := r.repo.Tag("v1.0.0")
+	require.NoError(t, err)
+	pushed, err := remote.Tag("v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, local.Hash(), pushed.Hash())
+	tag, err := remote.TagObject(pushed.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, head, tag.Target)
+
+	require.NoError(t, s.DeleteTag(ctx, "v1.0.0", PushOptions{}))
+	_, err = r.repo.Tag("v1.0.0")
+	assert.Equal(t, git.ErrTagNotFound, err)
+	_, err = remote.Reference(plumbing.NewTagReferenceName("v1.0.0"), false)
+	assert.Error(t, err)
+}
+
+func TestPushTagFailureRemovesLocalTag(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	s := r.service("v")
+
+	opts := PushOptions{RemoteURL: filepath.Join(t.TempDir(), "missing")}
+	assert.Error(t, s.PushTag(context.Background(), "v1.0.0", "Release v1.0.0", "", opts))
+	_, err := r.repo.Tag("v1.0.0")
+	assert.Equal(t, git.ErrTagNotFound, err)
+}
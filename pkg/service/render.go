@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strings"
+	"text/template"
 
 	"github.com/fanny7d/semrel-gitlab/pkg/domain"
 	"github.com/pkg/errors"
@@ -13,6 +15,50 @@ import (
 // RenderService 提供渲染相关操作
 type RenderService struct {
 	changelogFile string
+	projectURL    string
+	projectPath   string
+	options       ReleaseNoteOptions
+}
+
+// ReleaseNoteOptions 是渲染发布说明和变更日志的选项
+type ReleaseNoteOptions struct {
+	// Template 是发布说明的模板文件，数据为 ReleaseNoteData，为空时使用默认格式
+	Template string
+	// IncludeAuthors 为 true 时在每个变更后列出提交作者
+	IncludeAuthors bool
+	// IncludeLinks 为 true 时提交哈希渲染为指向项目中该提交的链接
+	IncludeLinks bool
+	// Groups 是变更分组，为空时每个类别一节
+	Groups []domain.NoteGroup
+}
+
+// ReleaseNoteData 是发布说明模板的数据
+type ReleaseNoteData struct {
+	Tag      string
+	Version  string
+	Sections []ReleaseNoteSection
+	Links    []domain.ReleaseLink
+}
+
+// ReleaseNoteSection 是发布说明模板中的一节
+type ReleaseNoteSection struct {
+	Title   string
+	Changes []ReleaseNoteChange
+}
+
+// ReleaseNoteChange 是发布说明模板中的一个变更
+type ReleaseNoteChange struct {
+	Hash      string
+	ShortHash string
+	// URL 是提交的链接，没有项目 URL 时为空
+	URL             string
+	Scope           string
+	Subject         string
+	Author          string
+	Breaking        bool
+	BreakingMessage string
+	// References 是提交引用的议题和合并请求，可以确定地址的引用为 Markdown 链接
+	References []string
 }
 
 // NewRenderService 创建一个新的渲染服务
@@ -22,28 +68,98 @@ func NewRenderService(changelogFile string) *RenderService {
 	}
 }
 
+// SetProject 设置当前项目的 URL 和路径，用于把提交中引用的议题和合并请求渲染为链接
+func (s *RenderService) SetProject(projectURL, projectPath string) {
+	s.projectURL = projectURL
+	s.projectPath = projectPath
+}
+
+// SetOptions 设置发布说明的模板、作者、提交链接和变更分组
+func (s *RenderService) SetOptions(options ReleaseNoteOptions) {
+	s.options = options
+}
+
+// sections 返回发布说明中的各节，没有配置分组时不列出 other 类别，类别名称首字母大写作为标题
+func (s *RenderService) sections(release *domain.Release) []domain.NoteSection {
+	sections := make([]domain.NoteSection, 0)
+	for _, section := range release.Sections(s.options.Groups) {
+		if section.Category == "other" {
+			continue
+		}
+		if section.Category != "" {
+			section.Title = strings.Title(section.Category)
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// commitURL 返回提交的链接，没有项目 URL 时返回空字符串
+func (s *RenderService) commitURL(change *domain.Commit) string {
+	if s.projectURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(s.projectURL, "/") + "/-/commit/" + change.Hash
+}
+
+// hash 渲染提交的短哈希，设置了 IncludeLinks 时渲染为链接
+func (s *RenderService) hash(change *domain.Commit) string {
+	short := change.Hash
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	if url := s.commitURL(change); s.options.IncludeLinks && url != "" {
+		return fmt.Sprintf("[%s](%s)", short, url)
+	}
+	return short
+}
+
+// author 渲染设置了 IncludeAuthors 时列在变更后的提交作者
+func (s *RenderService) author(change *domain.Commit) string {
+	if !s.options.IncludeAuthors || change.Author == "" {
+		return ""
+	}
+	return " - " + change.Author
+}
+
+// references 渲染提交引用的议题和合并请求，可以确定地址的引用渲染为链接
+func (s *RenderService) references(change *domain.Commit) []string {
+	refs := make([]string, 0, len(change.References))
+	for _, ref := range change.References {
+		if url := ref.URL(s.projectURL, s.projectPath); url != "" {
+			refs = append(refs, fmt.Sprintf("[%s](%s)", ref, url))
+		} else {
+			refs = append(refs, ref.String())
+		}
+	}
+	return refs
+}
+
 // RenderReleaseNote 渲染发布说明
 func (s *RenderService) RenderReleaseNote(release *domain.Release) error {
 	var buf bytes.Buffer
 
+	if s.options.Template != "" {
+		message, err := s.renderTemplate(release)
+		if err != nil {
+			return err
+		}
+		release.Message = message
+		return nil
+	}
+
 	// 渲染标题
 	buf.WriteString(fmt.Sprintf("# %s\n\n", release.TagName))
 
 	// 渲染变更列表
-	for category, changes := range release.Changes {
-		if category == "other" {
-			continue
-		}
-
-		buf.WriteString(fmt.Sprintf("## %s\n\n", strings.Title(category)))
-		for _, change := range changes {
-			if change.IsPreReleased() {
-				continue
-			}
+	for _, section := range s.sections(release) {
+		buf.WriteString(fmt.Sprintf("## %s\n\n", section.Title))
+		for _, change := range section.Changes {
+			details := strings.Join(append([]string{s.hash(change)}, s.references(change)...), ", ")
 			if change.Scope != "" {
-				buf.WriteString(fmt.Sprintf("* **%s:** %s (%s)\n", change.Scope, change.Subject, change.Hash[:7]))
+				buf.WriteString(fmt.Sprintf("* **%s:** %s (%s)%s\n", change.Scope, change.Subject, details, s.author(change)))
 			} else {
-				buf.WriteString(fmt.Sprintf("* %s (%s)\n", change.Subject, change.Hash[:7]))
+				buf.WriteString(fmt.Sprintf("* %s (%s)%s\n", change.Subject, details, s.author(change)))
 			}
 		}
 		buf.WriteString("\n")
@@ -68,12 +184,12 @@ func (s *RenderService) RenderReleaseNote(release *domain.Release) error {
 
 // UpdateChangelog 更新更新日志文件
 func (s *RenderService) UpdateChangelog(release *domain.Release) error {
-	entry := s.renderChangelogEntry(release)
+	entry := s.ChangelogEntry(release)
 
 	// 读取现有的更新日志文件
 	content, err := ioutil.ReadFile(s.changelogFile)
 	if err != nil {
-		if !errors.Is(err, errors.New("file does not exist")) {
+		if !os.IsNotExist(err) {
 			return errors.Wrap(err, "读取更新日志文件失败")
 		}
 		// 创建新的更新日志文件
@@ -101,30 +217,76 @@ func (s *RenderService) UpdateChangelog(release *domain.Release) error {
 	return ioutil.WriteFile(s.changelogFile, []byte(data), 0644)
 }
 
-// renderChangelogEntry 渲染更新日志条目
-func (s *RenderService) renderChangelogEntry(release *domain.Release) string {
+// ChangelogEntry 渲染更新日志条目
+func (s *RenderService) ChangelogEntry(release *domain.Release) string {
 	var buf bytes.Buffer
 
 	buf.WriteString(fmt.Sprintf("## %s\n\n", release.TagName))
 
-	for category, changes := range release.Changes {
-		if category == "other" {
-			continue
-		}
-
-		buf.WriteString(fmt.Sprintf("### %s\n\n", strings.Title(category)))
-		for _, change := range changes {
-			if change.IsPreReleased() {
-				continue
-			}
+	for _, section := range s.sections(release) {
+		buf.WriteString(fmt.Sprintf("### %s\n\n", section.Title))
+		for _, change := range section.Changes {
+			line := change.Subject
 			if change.Scope != "" {
-				buf.WriteString(fmt.Sprintf("* **%s:** %s\n", change.Scope, change.Subject))
-			} else {
-				buf.WriteString(fmt.Sprintf("* %s\n", change.Subject))
+				line = fmt.Sprintf("**%s:** %s", change.Scope, change.Subject)
+			}
+			refs := s.references(change)
+			if s.options.IncludeLinks && s.commitURL(change) != "" {
+				refs = append([]string{s.hash(change)}, refs...)
+			}
+			if len(refs) > 0 {
+				line += fmt.Sprintf(" (%s)", strings.Join(refs, ", "))
 			}
+			buf.WriteString(fmt.Sprintf("* %s%s\n", line, s.author(change)))
 		}
 		buf.WriteString("\n")
 	}
 
 	return buf.String()
 }
+
+// renderTemplate 按模板文件渲染发布说明
+func (s *RenderService) renderTemplate(release *domain.Release) (string, error) {
+	content, err := os.ReadFile(s.options.Template)
+	if err != nil {
+		return "", errors.Wrap(err, "读取发布说明模板失败")
+	}
+	tmpl, err := template.New(s.options.Template).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", errors.Wrap(err, "解析发布说明模板失败")
+	}
+
+	data := ReleaseNoteData{
+		Tag:      release.TagName,
+		Version:  release.Version.NextString(),
+		Sections: make([]ReleaseNoteSection, 0),
+		Links:    release.Links,
+	}
+	for _, section := range s.sections(release) {
+		changes := make([]ReleaseNoteChange, 0, len(section.Changes))
+		for _, c := range section.Changes {
+			change := ReleaseNoteChange{
+				Hash:            c.Hash,
+				ShortHash:       c.Hash,
+				URL:             s.commitURL(c),
+				Scope:           c.Scope,
+				Subject:         c.Subject,
+				Author:          c.Author,
+				Breaking:        c.Breaking,
+				BreakingMessage: c.BreakingMessage,
+				References:      s.references(c),
+			}
+			if len(change.ShortHash) > 7 {
+				change.ShortHash = change.ShortHash[:7]
+			}
+			changes = append(changes, change)
+		}
+		data.Sections = append(data.Sections, ReleaseNoteSection{Title: section.Title, Changes: changes})
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrap(err, "渲染发布说明模板失败")
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderReferences(t *testing.T) {
+	version := domain.NewVersion(time.Now())
+	version.Bump(domain.BumpMinor)
+	release := domain.NewRelease(version, "v")
+	release.AddChange("feat", domain.ParseCommit("0123456789abcdef", "feat(auth): login\n\nCloses #12\nRefs: PROJ-45, other/app!3"))
+
+	s := NewRenderService("")
+	s.SetProject("https://gitlab.example.com/group/proj", "group/proj")
+	require.NoError(t, s.RenderReleaseNote(release))
+	assert.Contains(t, release.Message, "* **auth:** login (0123456, [#12](https://gitlab.example.com/group/proj/-/issues/12), [other/app!3](https://gitlab.example.com/other/app/-/merge_requests/3), PROJ-45)\n")
+	assert.Contains(t, s.ChangelogEntry(release), "* **auth:** login ([#12](https://gitlab.example.com/group/proj/-/issues/12), [other/app!3](https://gitlab.example.com/other/app/-/merge_requests/3), PROJ-45)\n")
+
+	// 没有项目 URL 时引用不渲染为链接
+	require.NoError(t, NewRenderService("").RenderReleaseNote(release))
+	assert.Contains(t, release.Message, "* **auth:** login (0123456, #12, other/app!3, PROJ-45)\n")
+}
+
+func TestRenderReleaseNoteOptions(t *testing.T) {
+	version := domain.NewVersion(time.Now())
+	version.Bump(domain.BumpMinor)
+	release := domain.NewRelease(version, "v")
+	feat := domain.ParseCommit("0123456789abcdef", "feat: login")
+	feat.Author = "Alice"
+	fix := domain.ParseCommit("fedcba9876543210", "fix(api): timeout")
+	fix.Author = "Bob"
+	release.AddChange("feat", feat)
+	release.AddChange("fix", fix)
+	release.AddChange("docs", domain.ParseCommit("1111111111111111", "docs: readme"))
+
+	s := NewRenderService("")
+	s.SetProject("https://gitlab.example.com/group/proj", "group/proj")
+	s.SetOptions(ReleaseNoteOptions{
+		IncludeAuthors: true,
+		IncludeLinks:   true,
+		Groups: []domain.NoteGroup{
+			{Title: "修复", Types: []string{"fix"}},
+			{Title: "新功能", Types: []string{"feat"}},
+		},
+	})
+	require.NoError(t, s.RenderReleaseNote(release))
+	assert.Equal(t, "# v0.1.0\n\n"+
+		"## 修复\n\n* **api:** timeout ([fedcba9](https://gitlab.example.com/group/proj/-/commit/fedcba9876543210)) - Bob\n\n"+
+		"## 新功能\n\n* login ([0123456](https://gitlab.example.com/group/proj/-/commit/0123456789abcdef)) - Alice\n\n", release.Message)
+	assert.Equal(t, "## v0.1.0\n\n"+
+		"### 修复\n\n* **api:** timeout ([fedcba9](https://gitlab.example.com/group/proj/-/commit/fedcba9876543210)) - Bob\n\n"+
+		"### 新功能\n\n* login ([0123456](https://gitlab.example.com/group/proj/-/commit/0123456789abcdef)) - Alice\n\n", s.ChangelogEntry(release))
+
+	// 模板文件
+	tmpl := filepath.Join(t.TempDir(), "release.md")
+	require.NoError(t, os.WriteFile(tmpl, []byte("Release {{ .Version }}\n{{ range .Sections }}{{ .Title }}:{{ range .Changes }} {{ .ShortHash }} {{ .Subject }} by {{ .Author }};{{ end }}\n{{ end }}"), 0o644))
+	s.SetOptions(ReleaseNoteOptions{Template: tmpl})
+	require.NoError(t, s.RenderReleaseNote(release))
+	assert.Equal(t, "Release 0.1.0\nFeat: 0123456 login by Alice;\nFix: fedcba9 timeout by Bob;\nDocs: 1111111 readme by ;\n", release.Message)
+
+	require.NoError(t, os.WriteFile(tmpl, []byte("{{ .Date }}"), 0o644))
+	assert.Error(t, s.RenderReleaseNote(release))
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// gpgKeys 生成以 name 为主身份的 OpenPGP 密钥，aliases 是附加的身份，返回 ASCII armor 格式的私钥和公钥
+func gpgKeys(t *testing.T, name string, aliases ...string) ([]byte, []byte) {
+	t.Helper()
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	require.NoError(t, err)
+	for _, alias := range aliases {
+		require.NoError(t, entity.AddUserId(alias, "", alias+"@example.com", nil))
+	}
+
+	var private bytes.Buffer
+	w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.SerializePrivate(w, nil))
+	require.NoError(t, w.Close())
+
+	var public bytes.Buffer
+	w, err = armor.Encode(&public, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return private.Bytes(), public.Bytes()
+}
+
+// sshKeys 生成 SSH 密钥，返回 OpenSSH 格式的私钥和 authorized_keys 格式的公钥
+func sshKeys(t *testing.T, key interface{}, passphrase string) ([]byte, []byte) {
+	t.Helper()
+	var block *pem.Block
+	var err error
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(key, "test")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(key, "test", []byte(passphrase))
+	}
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(block), ssh.MarshalAuthorizedKey(signer.PublicKey())
+}
+
+func newEd25519(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func TestSignedTags(t *testing.T) {
+	gpgPrivate, gpgPublic := gpgKeys(t, "release")
+	_, otherGPGPublic := gpgKeys(t, "other")
+	edKey := newEd25519(t)
+	edPrivate, edPublic := sshKeys(t, edKey, "secret")
+	_, otherSSHPublic := sshKeys(t, newEd25519(t), "")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPrivate, rsaPublic := sshKeys(t, rsaKey, "")
+
+	tests := []struct {
+		name       string
+		key        []byte
+		passphrase string
+		trusted    []byte
+		untrusted  []byte
+		signer     string
+	}{
+		{"gpg", gpgPrivate, "", gpgPublic, otherGPGPublic, "release <release@example.com>"},
+		{"ssh ed25519 with passphrase", edPrivate, "secret", append([]byte("release@example.com namespaces=\"git\" "), edPublic...), otherSSHPublic, "release@example.com"},
+		{"ssh rsa", rsaPrivate, "", rsaPublic, otherSSHPublic, "SSH 密钥"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			r.commit("feat: first")
+			s := r.service("v")
+
+			signer, err := NewTagSigner(tt.key, tt.passphrase)
+			require.NoError(t, err)
+			s.SetSigner(signer)
+			require.NoError(t, s.CreateTag("v1.0.0", "Release v1.0.0", ""))
+
+			signedBy, err := s.VerifyTag("v1.0.0", tt.trusted)
+			require.NoError(t, err)
+			assert.Contains(t, signedBy, tt.signer)
+
+			_, err = s.VerifyTag("v1.0.0", tt.untrusted)
+			assert.Error(t, err)
+
+			// 签名标签仍然可以被 go-git 正常解析
+			ref, err := r.repo.Tag("v1.0.0")
+			require.NoError(t, err)
+			tag, err := r.repo.TagObject(ref.Hash())
+			require.NoError(t, err)
+			assert.Equal(t, "v1.0.0", tag.Name)
+		})
+	}
+}
+
+func TestVerifyTagPrimaryIdentity(t *testing.T) {
+	private, public := gpgKeys(t, "release", "alias1", "alias2", "alias3")
+	r := newTestRepo(t)
+	r.commit("feat: first")
+	s := r.service("v")
+	signer, err := NewTagSigner(private, "")
+	require.NoError(t, err)
+	s.SetSigner(signer)
+	require.NoError(t, s.CreateTag("v1.0.0", "Release v1.0.0", ""))
+
+	// 密钥有多个身份时总是报告主身份
+	for i := 0; i < 20; i++ {
+		signedBy, err := s.VerifyTag("v1.0.0", public)
+		require.NoError(t, err)
+		assert.Contains(t, signedBy, "release <release@example.com>")
+	}
+}
+
+func TestVerifyTagUnsigned(t *testing.T) {
+	r := newTestRepo(t)
+	head := r.commit("feat: first")
+	r.lightweightTag("v1.0.0", head)
+	s := r.service("v")
+	require.NoError(t, s.CreateTag("v1.1.0", "Release v1.1.0", ""))
+
+	_, public := gpgKeys(t, "release")
+	_, err := s.VerifyTag("v1.0.0", public)
+	assert.Error(t, err)
+	_, err = s.VerifyTag("v1.1.0", public)
+	assert.Error(t, err)
+}
+
+func TestNewTagSignerPassphrase(t *testing.T) {
+	key, _ := sshKeys(t, newEd25519(t), "secret")
+	_, err := NewTagSigner(key, "")
+	assert.Error(t, err)
+	_, err = NewTagSigner(key, "wrong")
+	assert.Error(t, err)
+	_, err = NewTagSigner([]byte("not a key"), "")
+	assert.Error(t, err)
+}
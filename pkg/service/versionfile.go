@@ -0,0 +1,93 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/pkg/errors"
+)
+
+// UpdateVersionFiles 把目录 dir 中的版本文件更新为 version，返回内容有变化的文件，
+// 返回的路径与 VersionFile.Path 相同，可以直接作为提交的文件。dryRun 为 true 时只检查不写入
+func UpdateVersionFiles(dir string, files []domain.VersionFile, version string, dryRun bool) ([]string, error) {
+	changed := make([]string, 0, len(files))
+	for _, f := range files {
+		name := filepath.Join(dir, filepath.FromSlash(f.Path))
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "读取版本文件失败")
+		}
+		updated, err := f.Update(content, version)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(content, updated) {
+			continue
+		}
+		if !dryRun {
+			info, err := os.Stat(name)
+			if err != nil {
+				return nil, errors.Wrap(err, "读取版本文件失败")
+			}
+			if err := os.WriteFile(name, updated, info.Mode().Perm()); err != nil {
+				return nil, errors.Wrap(err, "写入版本文件失败")
+			}
+		}
+		changed = append(changed, f.Path)
+	}
+	return changed, nil
+}
+
+// FileSnapshot 保存文件修改前的内容，发布失败时用于恢复本地工作区
+type FileSnapshot struct {
+	paths    []string
+	contents map[string][]byte
+	// modes 是文件的权限，保存时不存在的文件没有记录
+	modes map[string]os.FileMode
+}
+
+// SnapshotFiles 保存 paths 中文件当前的内容，不存在的文件在恢复时删除
+func SnapshotFiles(paths ...string) (*FileSnapshot, error) {
+	s := &FileSnapshot{contents: make(map[string][]byte), modes: make(map[string]os.FileMode)}
+	for _, path := range paths {
+		if slices.Contains(s.paths, path) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			s.paths = append(s.paths, path)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "读取文件失败")
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "读取文件失败")
+		}
+		s.paths = append(s.paths, path)
+		s.contents[path] = content
+		s.modes[path] = info.Mode().Perm()
+	}
+	return s, nil
+}
+
+// Restore 把文件恢复为保存时的内容，保存时不存在的文件被删除
+func (s *FileSnapshot) Restore() error {
+	for _, path := range s.paths {
+		mode, existed := s.modes[path]
+		if !existed {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return errors.Wrapf(err, "删除文件 %s 失败", path)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, s.contents[path], mode); err != nil {
+			return errors.Wrapf(err, "恢复文件 %s 失败", path)
+		}
+	}
+	return nil
+}
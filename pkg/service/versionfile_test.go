@@ -0,0 +1,84 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fanny7d/semrel-gitlab/pkg/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateVersionFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("VERSION", "1.2.3\n")
+	write("charts/app/Chart.yaml", "name: app\nversion: 1.3.0\nappVersion: 1.3.0\n")
+	write("package.json", "{\"version\": \"1.2.3\"}\n")
+
+	files := make([]domain.VersionFile, 0)
+	for _, name := range []string{"VERSION", "charts/app/Chart.yaml", "package.json"} {
+		f, err := domain.NewVersionFile(name, "", "", "")
+		require.NoError(t, err)
+		files = append(files, f)
+	}
+
+	// 预览模式不修改文件
+	changed, err := UpdateVersionFiles(dir, files, "1.3.0", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VERSION", "package.json"}, changed)
+	content, err := os.ReadFile(filepath.Join(dir, "VERSION"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3\n", string(content))
+
+	// 已经是新版本的文件不作为变更
+	changed, err = UpdateVersionFiles(dir, files, "1.3.0", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VERSION", "package.json"}, changed)
+	content, err = os.ReadFile(filepath.Join(dir, "package.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"version\": \"1.3.0\"}\n", string(content))
+
+	// 文件不存在或没有版本号时返回错误
+	missing, err := domain.NewVersionFile("Cargo.toml", "", "", "")
+	require.NoError(t, err)
+	_, err = UpdateVersionFiles(dir, []domain.VersionFile{missing}, "1.3.0", false)
+	assert.Error(t, err)
+	write("Cargo.toml", "[workspace]\nmembers = [\"a\"]\n")
+	_, err = UpdateVersionFiles(dir, []domain.VersionFile{missing}, "1.3.0", false)
+	assert.Error(t, err)
+}
+
+func TestFileSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	version := filepath.Join(dir, "VERSION")
+	empty := filepath.Join(dir, "EMPTY")
+	changelog := filepath.Join(dir, "CHANGELOG.md")
+	require.NoError(t, os.WriteFile(version, []byte("1.2.3\n"), 0o600))
+	require.NoError(t, os.WriteFile(empty, nil, 0o644))
+
+	snapshot, err := SnapshotFiles(version, empty, changelog, version)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(version, []byte("1.3.0\n"), 0o600))
+	require.NoError(t, os.WriteFile(empty, []byte("x"), 0o644))
+	require.NoError(t, os.WriteFile(changelog, []byte("# CHANGELOG\n"), 0o644))
+
+	require.NoError(t, snapshot.Restore())
+	content, err := os.ReadFile(version)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3\n", string(content))
+	info, err := os.Stat(version)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	content, err = os.ReadFile(empty)
+	require.NoError(t, err)
+	assert.Empty(t, content)
+	assert.NoFileExists(t, changelog)
+
+	// 恢复可以重复执行
+	require.NoError(t, snapshot.Restore())
+}
@@ -0,0 +1,193 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// JournalVersion is the format version written to journal files
+const JournalVersion = 1
+
+// Journaled is implemented by actions whose results can be saved
+// to a journal and restored by a later run of the same workflow.
+type Journaled interface {
+	Action
+	// ID identifies the action within the workflow.
+	// It must be unique in the workflow and stable between runs.
+	ID() string
+	// State returns the values produced by Do, e.g. a commit SHA or an upload URL.
+	// It is only called after Do succeeded.
+	State() map[string]string
+	// Restore marks the action as done with the values returned by State,
+	// so that Do does nothing and Undo can clean up.
+	Restore(state map[string]string) error
+}
+
+// JournalEntry records one completed action
+type JournalEntry struct {
+	ID    string            `json:"id"`
+	State map[string]string `json:"state,omitempty"`
+}
+
+// Journal is the persisted list of completed actions of a workflow.
+// Only actions implementing Journaled are recorded.
+type Journal struct {
+	Version   int            `json:"version"`
+	Completed []JournalEntry `json:"completed"`
+
+	path string
+}
+
+// OpenJournal reads the journal at path.
+// If the file does not exist, an empty journal is returned.
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{Version: JournalVersion, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read journal %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("parse journal %s: %v", path, err)
+	}
+	if j.Version != JournalVersion {
+		return nil, fmt.Errorf("journal %s has unsupported version %d", path, j.Version)
+	}
+	return j, nil
+}
+
+// Empty reports whether the journal has no completed actions
+func (j *Journal) Empty() bool {
+	return len(j.Completed) == 0
+}
+
+// restore restores the recorded state into the matching actions.
+// It fails if the journal records an action that is not part of the workflow,
+// because the journal then belongs to a different release.
+func (j *Journal) restore(actions []Action) error {
+	byID := make(map[string]Journaled)
+	for _, action := range actions {
+		if journaled, ok := action.(Journaled); ok {
+			byID[journaled.ID()] = journaled
+		}
+	}
+	for _, entry := range j.Completed {
+		action, ok := byID[entry.ID]
+		if !ok {
+			return fmt.Errorf("journal %s does not match the workflow: unknown action %s", j.path, entry.ID)
+		}
+		if err := action.Restore(entry.State); err != nil {
+			return fmt.Errorf("restore %s from journal %s: %v", entry.ID, j.path, err)
+		}
+	}
+	return nil
+}
+
+// record saves the state of a completed action
+func (j *Journal) record(action Action) error {
+	journaled, ok := action.(Journaled)
+	if !ok {
+		return nil
+	}
+	entry := JournalEntry{ID: journaled.ID(), State: journaled.State()}
+	for i := range j.Completed {
+		if j.Completed[i].ID == entry.ID {
+			j.Completed[i] = entry
+			return j.save()
+		}
+	}
+	j.Completed = append(j.Completed, entry)
+	return j.save()
+}
+
+// forget removes an action that has been undone
+func (j *Journal) forget(action Action) error {
+	journaled, ok := action.(Journaled)
+	if !ok {
+		return nil
+	}
+	for i := range j.Completed {
+		if j.Completed[i].ID == journaled.ID() {
+			j.Completed = append(j.Completed[:i], j.Completed[i+1:]...)
+			return j.save()
+		}
+	}
+	return nil
+}
+
+// save writes the journal atomically, so that an interrupted write
+// never leaves a truncated journal behind
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return fmt.Errorf("write journal %s: %v", j.path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write journal %s: %v", j.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write journal %s: %v", j.path, err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("write journal %s: %v", j.path, err)
+	}
+	return nil
+}
+
+// remove deletes the journal file
+func (j *Journal) remove() error {
+	j.Completed = nil
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove journal %s: %v", j.path, err)
+	}
+	return nil
+}
+
+// ApplyWithJournal is like ApplyWithPolicy, but records every completed action
+// in the journal at path. If the journal already exists, the recorded
+// actions are restored first and the workflow resumes after them.
+// The journal is removed when the workflow succeeds or has been rolled back
+// completely, otherwise it is kept for RollbackJournal or another attempt.
+func ApplyWithJournal(ctx context.Context, actions []Action, path string, policy RetryPolicy) error {
+	j, err := OpenJournal(path)
+	if err != nil {
+		return err
+	}
+	if !j.Empty() {
+		if err := j.restore(actions); err != nil {
+			return err
+		}
+		fmt.Printf("Resuming workflow from journal %s, %d actions already done\n", path, len(j.Completed))
+	}
+	return apply(ctx, actions, policy, j)
+}
+
+// RollbackJournal undoes the actions recorded in the journal at path
+// and removes the journal.
+func RollbackJournal(ctx context.Context, actions []Action, path string) error {
+	j, err := OpenJournal(path)
+	if err != nil {
+		return err
+	}
+	if j.Empty() {
+		return fmt.Errorf("no completed actions in journal %s", path)
+	}
+	if err := j.restore(actions); err != nil {
+		return err
+	}
+	if err := rollback(ctx, actions, j); err != nil {
+		return fmt.Errorf("rollback from journal %s incomplete: %v", path, err)
+	}
+	return j.remove()
+}
@@ -0,0 +1,156 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// journaledAction 是一个测试用的 Journaled 实现
+type journaledAction struct {
+	id        string
+	doError   *ActionError
+	doCount   int
+	undoCount int
+	result    string
+}
+
+func (a *journaledAction) Do(ctx context.Context) *ActionError {
+	if a.result != "" {
+		return nil
+	}
+	a.doCount++
+	if a.doError != nil {
+		return a.doError
+	}
+	a.result = a.id + "-result"
+	return nil
+}
+
+func (a *journaledAction) Undo(ctx context.Context) error {
+	if a.result == "" {
+		return nil
+	}
+	a.undoCount++
+	a.result = ""
+	return nil
+}
+
+func (a *journaledAction) Describe() string {
+	return a.id
+}
+
+func (a *journaledAction) ID() string {
+	return a.id
+}
+
+func (a *journaledAction) State() map[string]string {
+	return map[string]string{"result": a.result}
+}
+
+func (a *journaledAction) Restore(state map[string]string) error {
+	if state["result"] == "" {
+		return errors.New("result missing")
+	}
+	a.result = state["result"]
+	return nil
+}
+
+func writeJournal(t *testing.T, path string, entries ...JournalEntry) {
+	t.Helper()
+	j := &Journal{Version: JournalVersion, Completed: entries, path: path}
+	if err := j.save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyWithJournal_Success(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	first := &journaledAction{id: "first"}
+	second := &journaledAction{id: "second"}
+	if err := ApplyWithJournal(context.Background(), []Action{first, second}, path, testPolicy); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected journal to be removed after success")
+	}
+}
+
+func TestApplyWithJournal_Resume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	writeJournal(t, path, JournalEntry{ID: "first", State: map[string]string{"result": "saved"}})
+
+	first := &journaledAction{id: "first"}
+	second := &journaledAction{id: "second"}
+	if err := ApplyWithJournal(context.Background(), []Action{first, second}, path, testPolicy); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.doCount != 0 {
+		t.Error("Expected completed action not to be done again")
+	}
+	if first.result != "saved" {
+		t.Errorf("Expected state to be restored, got %q", first.result)
+	}
+	if second.doCount != 1 {
+		t.Error("Expected remaining action to be done")
+	}
+}
+
+func TestApplyWithJournal_KeepsProgressWhenRollbackFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	first := &journaledAction{id: "first"}
+	stuck := &TestAction{undoError: errors.New("undo failed")}
+	failing := &journaledAction{id: "failing", doError: NewActionError(errors.New("do failed"), false)}
+	if err := ApplyWithJournal(context.Background(), []Action{stuck, first, failing}, path, testPolicy); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !j.Empty() {
+		t.Errorf("Expected undone actions to be removed from journal, got %v", j.Completed)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error("Expected journal to be kept after incomplete rollback")
+	}
+}
+
+func TestApplyWithJournal_Mismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	writeJournal(t, path, JournalEntry{ID: "other", State: map[string]string{"result": "saved"}})
+
+	action := &journaledAction{id: "first"}
+	if err := ApplyWithJournal(context.Background(), []Action{action}, path, testPolicy); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if action.doCount != 0 {
+		t.Error("Expected Do not to be called")
+	}
+}
+
+func TestRollbackJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+	writeJournal(t, path, JournalEntry{ID: "first", State: map[string]string{"result": "saved"}})
+
+	first := &journaledAction{id: "first"}
+	second := &journaledAction{id: "second"}
+	if err := RollbackJournal(context.Background(), []Action{first, second}, path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.undoCount != 1 {
+		t.Error("Expected recorded action to be undone")
+	}
+	if second.undoCount != 0 || second.doCount != 0 {
+		t.Error("Expected unrecorded action not to be touched")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected journal to be removed after rollback")
+	}
+
+	if err := RollbackJournal(context.Background(), []Action{first}, path); err == nil {
+		t.Error("Expected error for missing journal")
+	}
+}
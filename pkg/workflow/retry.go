@@ -0,0 +1,91 @@
+package workflow
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how often and how long to wait before
+// a failed action with a retryable error is done again.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// InitialDelay is the delay before the first retry
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between retries. A Retry-After longer
+	// than MaxDelay is not waited for, the action fails instead.
+	MaxDelay time.Duration
+	// Multiplier is applied to the delay after each retry
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in both directions
+	Jitter float64
+	// Sleep waits for the given duration or until ctx is done.
+	// If nil, a timer is used.
+	Sleep func(ctx context.Context, d time.Duration) error
+	// Random returns a number in [0, 1), rand.Float64 if nil
+	Random func() float64
+}
+
+// DefaultRetryPolicy is used by Apply
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:   3,
+	InitialDelay: 2 * time.Second,
+	MaxDelay:     time.Minute,
+	Multiplier:   2,
+	Jitter:       0.2,
+}
+
+// PolicyOverride is implemented by actions that need a different
+// retry policy than the rest of the workflow.
+type PolicyOverride interface {
+	RetryPolicy(p RetryPolicy) RetryPolicy
+}
+
+// policyFor returns the retry policy for action
+func (p RetryPolicy) policyFor(action Action) RetryPolicy {
+	if override, ok := action.(PolicyOverride); ok {
+		return override.RetryPolicy(p)
+	}
+	return p
+}
+
+// Delay returns how long to wait before retry number attempt (starting at 1).
+// retryAfter is the delay requested by the server, zero if none.
+// ok is false if the server asked to wait longer than MaxDelay.
+func (p RetryPolicy) Delay(attempt int, retryAfter time.Duration) (delay time.Duration, ok bool) {
+	if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+		return 0, false
+	}
+	backoff := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.Jitter > 0 {
+		random := p.Random
+		if random == nil {
+			random = rand.Float64
+		}
+		backoff *= 1 + p.Jitter*(2*random()-1)
+	}
+	if p.MaxDelay > 0 && backoff > float64(p.MaxDelay) {
+		backoff = float64(p.MaxDelay)
+	}
+	delay = time.Duration(backoff)
+	if delay < retryAfter {
+		delay = retryAfter
+	}
+	return delay, true
+}
+
+func (p RetryPolicy) sleep(ctx context.Context, d time.Duration) error {
+	if p.Sleep != nil {
+		return p.Sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,23 +1,19 @@
 package workflow
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 )
 
-const (
-	// RetryCount is the number of retrys after 502 erros
-	RetryCount = 3
-	// RetryDelay is the number of seconds to wait before a retry
-	RetryDelay = 10
-)
-
 // ActionError wraps the original error with a flag
 // indicating if the action should be retried
 type ActionError struct {
-	retry bool
-	err   error
+	retry      bool
+	retryAfter time.Duration
+	err        error
 }
 
 // Error implements error interface
@@ -33,70 +29,174 @@ func NewActionError(err error, retry bool) *ActionError {
 	}
 }
 
+// NewRetryAfterError creates a retryable action error for a server
+// that asked to wait at least retryAfter before the next attempt
+func NewRetryAfterError(err error, retryAfter time.Duration) *ActionError {
+	return &ActionError{
+		retry:      true,
+		retryAfter: retryAfter,
+		err:        err,
+	}
+}
+
+// Retryable reports whether the action may succeed if it is done again
+func (e *ActionError) Retryable() bool {
+	return e.retry
+}
+
+// RetryAfter returns the delay requested by the server, zero if none
+func (e *ActionError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
 // Action must be idemponent or return an error if that's not possible.
 // It is possible that Undo is called before Do.
+// Do and Undo should stop and return an error when ctx is done.
+// Describe returns a human readable description of what Do would do,
+// it must not have side effects.
 type Action interface {
-	Do() *ActionError
-	Undo() error
+	Do(ctx context.Context) *ActionError
+	Undo(ctx context.Context) error
+	Describe() string
 }
 
-func doActions(actions []Action) (bool, error) {
-	for _, action := range actions {
-		aErr := action.Do()
-		if aErr != nil {
-			return aErr.retry, aErr.err
+// RollbackTimeout limits how long the rollback may take.
+// The rollback runs even if the context of the workflow is already done,
+// e.g. after a timeout or when the job was cancelled.
+var RollbackTimeout = 2 * time.Minute
+
+// Pending is used in descriptions for values that are known only
+// after a preceding action has been done.
+const Pending = "<待定>"
+
+// Plan writes a numbered list of the action descriptions to w
+// without doing any of the actions.
+func Plan(w io.Writer, actions []Action) error {
+	for i, action := range actions {
+		lines := strings.Split(strings.TrimRight(action.Describe(), "\n"), "\n")
+		if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, lines[0]); err != nil {
+			return err
+		}
+		for _, line := range lines[1:] {
+			if _, err := fmt.Fprintf(w, "   %s\n", line); err != nil {
+				return err
+			}
 		}
 	}
-	return false, nil
+	return nil
 }
 
-// Apply tries to Do all actions.
-// Abort action sequence immediately, if an error occurs
-// If error is retryable, retry the sequence RetryCount times,
-// with RetryDelay seconds between retries.
-// If all retrys fail, rollback to clean up.
-func Apply(actions []Action) error {
-	var err error
-	for i := 0; i <= RetryCount; i++ {
-		if i > 0 {
-			fmt.Printf("Waiting %d seconds before retry...", RetryDelay)
-			time.Sleep(RetryDelay * time.Second)
-			fmt.Printf("Starting retry %d/%d\n", i, RetryCount)
+func doActions(ctx context.Context, actions []Action, policy RetryPolicy, journal *Journal) error {
+	for _, action := range actions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := doAction(ctx, action, policy.policyFor(action)); err != nil {
+			return err
 		}
-		retry, err := doActions(actions)
-		if err == nil {
+		if journal != nil {
+			if err := journal.record(action); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// doAction does a single action, retrying it according to policy
+func doAction(ctx context.Context, action Action, policy RetryPolicy) error {
+	for attempt := 1; ; attempt++ {
+		aErr := action.Do(ctx)
+		if aErr == nil {
 			return nil
 		}
-		fmt.Println(err.Error())
-		if !retry {
+		fmt.Println(aErr.Error())
+		if ctx.Err() != nil {
+			return fmt.Errorf("%v (%v)", aErr.err, ctx.Err())
+		}
+		if !aErr.retry {
 			fmt.Println(`
 Not trying to continue after this kind of error.
 If you think recovery should be attempted, please create an issue
 at https://github.com/fanny7d/semrel-gitlab/issues/new
 or by email to the project maintainers.`)
-			rollbackErr := rollback(actions)
-			if rollbackErr != nil {
-				fmt.Println(rollbackErr.Error())
-			}
-			return errors.New("workflow execution failed")
+			return aErr.err
+		}
+		if attempt > policy.MaxRetries {
+			return fmt.Errorf("%v (gave up after %d retries)", aErr.err, policy.MaxRetries)
+		}
+		delay, ok := policy.Delay(attempt, aErr.retryAfter)
+		if !ok {
+			return fmt.Errorf("%v (server asked to retry after %s)", aErr.err, aErr.retryAfter)
+		}
+		fmt.Printf("Waiting %s before retry %d/%d...\n", delay.Round(time.Millisecond), attempt, policy.MaxRetries)
+		if err := policy.sleep(ctx, delay); err != nil {
+			return fmt.Errorf("%v (%v)", aErr.err, err)
 		}
 	}
-	return err
+}
+
+// Apply tries to Do all actions with DefaultRetryPolicy.
+// Abort action sequence immediately, if an error occurs.
+// If error is retryable, only the failed action is retried,
+// as configured by the retry policy.
+// If the error is not retryable or all retrys fail, rollback to clean up.
+// When ctx is done, the current action and any wait for a retry are
+// interrupted and the completed actions are rolled back as well.
+// The returned error reports both the cause and the result of the rollback.
+func Apply(ctx context.Context, actions []Action) error {
+	return apply(ctx, actions, DefaultRetryPolicy, nil)
+}
+
+// ApplyWithPolicy is like Apply, but retries failed actions according to policy.
+func ApplyWithPolicy(ctx context.Context, actions []Action, policy RetryPolicy) error {
+	return apply(ctx, actions, policy, nil)
+}
+
+// apply implements Apply, recording progress in journal if it is not nil.
+func apply(ctx context.Context, actions []Action, policy RetryPolicy, journal *Journal) error {
+	err := doActions(ctx, actions, policy, journal)
+	if err == nil {
+		if journal != nil {
+			return journal.remove()
+		}
+		return nil
+	}
+	rollbackErr := rollback(ctx, actions, journal)
+	if rollbackErr == nil && journal != nil {
+		rollbackErr = journal.remove()
+	}
+	if rollbackErr != nil {
+		fmt.Println(rollbackErr.Error())
+		return fmt.Errorf("workflow execution failed: %v (rollback incomplete: %v)", err, rollbackErr)
+	}
+	return fmt.Errorf("workflow execution failed, completed actions were rolled back: %v", err)
 }
 
 // rollback tries to Undo all actions.
+// Undone actions are removed from journal if it is not nil.
+// The rollback is not cancelled with ctx, but limited by RollbackTimeout.
 //
 //	list of errors is returned
-func rollback(actions []Action) error {
+func rollback(ctx context.Context, actions []Action, journal *Journal) error {
 	errorCount := 0
 	if len(actions) == 0 {
 		return nil
 	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), RollbackTimeout)
+	defer cancel()
 	for i := len(actions) - 1; i >= 0; i-- {
-		err := actions[i].Undo()
+		err := actions[i].Undo(ctx)
 		if err != nil {
 			fmt.Println(err.Error())
 			errorCount++
+			continue
+		}
+		if journal != nil {
+			if err := journal.forget(actions[i]); err != nil {
+				fmt.Println(err.Error())
+				errorCount++
+			}
 		}
 	}
 	if errorCount > 0 {
@@ -1,8 +1,13 @@
 package workflow
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestAction 是一个测试用的 Action 实现
@@ -10,22 +15,28 @@ type TestAction struct {
 	doError    *ActionError
 	undoError  error
 	doCalled   bool
+	doCount    int
 	undoCalled bool
 }
 
-func (a *TestAction) Do() *ActionError {
+func (a *TestAction) Do(ctx context.Context) *ActionError {
 	a.doCalled = true
+	a.doCount++
 	return a.doError
 }
 
-func (a *TestAction) Undo() error {
+func (a *TestAction) Undo(ctx context.Context) error {
 	a.undoCalled = true
 	return a.undoError
 }
 
+func (a *TestAction) Describe() string {
+	return "test action\nsecond line"
+}
+
 func TestApply_Success(t *testing.T) {
 	action := &TestAction{}
-	err := Apply([]Action{action})
+	err := Apply(context.Background(), []Action{action})
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -42,9 +53,12 @@ func TestApply_Error(t *testing.T) {
 	action := &TestAction{
 		doError: NewActionError(expectedErr, false),
 	}
-	err := Apply([]Action{action})
+	err := Apply(context.Background(), []Action{action})
 	if err == nil {
-		t.Error("Expected error, got nil")
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), expectedErr.Error()) {
+		t.Errorf("Expected error to contain the cause, got %v", err)
 	}
 	if !action.doCalled {
 		t.Error("Expected Do to be called")
@@ -54,26 +68,185 @@ func TestApply_Error(t *testing.T) {
 	}
 }
 
+// testPolicy 是不等待的重试策略
+var testPolicy = RetryPolicy{
+	MaxRetries:   2,
+	InitialDelay: time.Second,
+	MaxDelay:     time.Minute,
+	Multiplier:   2,
+	Sleep:        func(context.Context, time.Duration) error { return nil },
+}
+
 func TestApply_RetryableError(t *testing.T) {
 	expectedErr := errors.New("test error")
+	done := &TestAction{}
 	action := &TestAction{
 		doError: NewActionError(expectedErr, true),
 	}
-	err := Apply([]Action{action})
+	var delays []time.Duration
+	policy := testPolicy
+	policy.Sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	err := ApplyWithPolicy(context.Background(), []Action{done, action}, policy)
 	if err == nil {
 		t.Error("Expected error, got nil")
 	}
-	if !action.doCalled {
-		t.Error("Expected Do to be called")
+	if action.doCount != 3 {
+		t.Errorf("Expected Do to be called 3 times, got %d", action.doCount)
+	}
+	if done.doCount != 1 {
+		t.Errorf("Expected only the failed action to be retried, preceding action done %d times", done.doCount)
+	}
+	if !reflect.DeepEqual(delays, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("Expected exponential backoff, got %v", delays)
+	}
+	if !action.undoCalled {
+		t.Error("Expected Undo to be called after all retries failed")
+	}
+}
+
+// flakyAction 在前几次执行时失败
+type flakyAction struct {
+	TestAction
+	failures int
+	err      *ActionError
+}
+
+func (a *flakyAction) Do(ctx context.Context) *ActionError {
+	a.doCount++
+	if a.doCount <= a.failures {
+		return a.err
+	}
+	return nil
+}
+
+func TestApply_RetrySucceeds(t *testing.T) {
+	action := &flakyAction{failures: 2, err: NewRetryAfterError(errors.New("rate limited"), 30*time.Second)}
+	var delays []time.Duration
+	policy := testPolicy
+	policy.Sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	if err := ApplyWithPolicy(context.Background(), []Action{action}, policy); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
 	}
 	if action.undoCalled {
 		t.Error("Expected Undo not to be called")
 	}
+	if !reflect.DeepEqual(delays, []time.Duration{30 * time.Second, 30 * time.Second}) {
+		t.Errorf("Expected Retry-After to be honored, got %v", delays)
+	}
+}
+
+func TestApply_RetryAfterTooLong(t *testing.T) {
+	action := &flakyAction{failures: 1, err: NewRetryAfterError(errors.New("rate limited"), time.Hour)}
+	if err := ApplyWithPolicy(context.Background(), []Action{action}, testPolicy); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if action.doCount != 1 {
+		t.Errorf("Expected no retry, got %d attempts", action.doCount)
+	}
+}
+
+// overrideAction 不允许重试
+type overrideAction struct {
+	TestAction
+}
+
+func (a *overrideAction) RetryPolicy(p RetryPolicy) RetryPolicy {
+	p.MaxRetries = 0
+	return p
+}
+
+func TestApply_PolicyOverride(t *testing.T) {
+	action := &overrideAction{TestAction{doError: NewActionError(errors.New("test error"), true)}}
+	if err := ApplyWithPolicy(context.Background(), []Action{action}, testPolicy); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if action.doCount != 1 {
+		t.Errorf("Expected no retry, got %d attempts", action.doCount)
+	}
+}
+
+// ctxAction 记录 Undo 时 ctx 的状态
+type ctxAction struct {
+	TestAction
+	undoCtxErr error
+}
+
+func (a *ctxAction) Undo(ctx context.Context) error {
+	a.undoCalled = true
+	a.undoCtxErr = ctx.Err()
+	return nil
+}
+
+func TestApply_Cancelled(t *testing.T) {
+	done := &ctxAction{}
+	failing := &TestAction{doError: NewActionError(errors.New("test error"), true)}
+	never := &TestAction{}
+	policy := testPolicy
+	policy.InitialDelay = time.Hour
+	policy.MaxDelay = time.Hour
+	policy.Sleep = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := ApplyWithPolicy(ctx, []Action{done, failing, never}, policy)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("Expected error to report the deadline, got %v", err)
+	}
+	if time.Since(start) > time.Minute {
+		t.Error("Expected the wait for a retry to be interrupted")
+	}
+	if failing.doCount != 1 || never.doCalled {
+		t.Error("Expected no more actions after cancellation")
+	}
+	if !done.undoCalled {
+		t.Error("Expected completed action to be rolled back")
+	}
+	if done.undoCtxErr != nil {
+		t.Errorf("Expected rollback not to be cancelled, got %v", done.undoCtxErr)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{
+		InitialDelay: time.Second,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   3,
+		Jitter:       0.5,
+		Random:       func() float64 { return 1 },
+	}
+	tests := []struct {
+		attempt    int
+		retryAfter time.Duration
+		expected   time.Duration
+		ok         bool
+	}{
+		{1, 0, 1500 * time.Millisecond, true},
+		{2, 0, 4500 * time.Millisecond, true},
+		{3, 0, 10 * time.Second, true},
+		{1, 5 * time.Second, 5 * time.Second, true},
+		{1, 11 * time.Second, 0, false},
+	}
+	for _, tt := range tests {
+		delay, ok := policy.Delay(tt.attempt, tt.retryAfter)
+		if delay != tt.expected || ok != tt.ok {
+			t.Errorf("Delay(%d, %s) = %s, %v; expected %s, %v", tt.attempt, tt.retryAfter, delay, ok, tt.expected, tt.ok)
+		}
+	}
 }
 
 func TestRollback_Success(t *testing.T) {
 	action := &TestAction{}
-	err := rollback([]Action{action})
+	err := rollback(context.Background(), []Action{action}, nil)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -87,7 +260,7 @@ func TestRollback_Error(t *testing.T) {
 	action := &TestAction{
 		undoError: expectedErr,
 	}
-	err := rollback([]Action{action})
+	err := rollback(context.Background(), []Action{action}, nil)
 	if err == nil {
 		t.Error("Expected error, got nil")
 	}
@@ -95,3 +268,19 @@ func TestRollback_Error(t *testing.T) {
 		t.Error("Expected Undo to be called")
 	}
 }
+
+func TestPlan(t *testing.T) {
+	first := &TestAction{}
+	second := &TestAction{}
+	var buf bytes.Buffer
+	if err := Plan(&buf, []Action{first, second}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "1. test action\n   second line\n2. test action\n   second line\n"
+	if buf.String() != expected {
+		t.Errorf("Expected plan %q, got %q", expected, buf.String())
+	}
+	if first.doCalled || second.doCalled {
+		t.Error("Expected Do not to be called")
+	}
+}